@@ -0,0 +1,250 @@
+// Package api serves REST API of the events calendar and iCal feeds,
+// it returns the same events as the bot does.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/austinov/rocker-bot/common"
+	"github.com/austinov/rocker-bot/config"
+	"github.com/austinov/rocker-bot/store"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+	// dateLayout is a layout of dates in requests and responses
+	dateLayout = "2006-01-02"
+)
+
+// Event is an event in responses.
+type Event struct {
+	Band    string `json:"band"`
+	Title   string `json:"title"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+	City    string `json:"city"`
+	Country string `json:"country,omitempty"`
+	Venue   string `json:"venue,omitempty"`
+	Address string `json:"address,omitempty"`
+	Link    string `json:"link,omitempty"`
+	Img     string `json:"img,omitempty"`
+}
+
+type EventsResponse struct {
+	Events []Event `json:"events"`
+	Offset int     `json:"offset"`
+	Limit  int     `json:"limit"`
+}
+
+type NamesResponse struct {
+	Names  []string `json:"names"`
+	Offset int      `json:"offset"`
+	Limit  int      `json:"limit"`
+}
+
+// Error is a body of error responses.
+type Error struct {
+	Error ErrorObject `json:"error"`
+}
+
+type ErrorObject struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Server serves REST API of the events calendar.
+type Server struct {
+	cfg config.APIConfig
+	dao store.Dao
+	mux *http.ServeMux
+	now func() time.Time
+}
+
+func New(cfg config.APIConfig, dao store.Dao) *Server {
+	s := &Server{
+		cfg: cfg,
+		dao: dao,
+		mux: http.NewServeMux(),
+		now: time.Now,
+	}
+	s.mux.HandleFunc("/events", s.get(s.events))
+	s.mux.HandleFunc("/bands", s.get(s.names(dao.GetBands)))
+	s.mux.HandleFunc("/cities", s.get(s.names(dao.GetCities)))
+	s.mux.HandleFunc("/ical/band/", s.get(s.ical("band")))
+	s.mux.HandleFunc("/ical/city/", s.get(s.ical("city")))
+	s.mux.HandleFunc("/openapi.json", s.get(s.openAPI))
+	s.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "not_found", "Unknown path "+r.URL.Path)
+	})
+	return s
+}
+
+// Start serves the API on the listen address until ctx is done.
+func (s *Server) Start(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.cfg.Listen)
+	if err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		ln.Close()
+	}()
+	err = http.Serve(ln, s)
+	if ctx.Err() != nil {
+		// the listener is closed on stop
+		return nil
+	}
+	return err
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// get allows only GET requests to the handler.
+func (s *Server) get(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method "+r.Method+" is not allowed")
+			return
+		}
+		h(w, r)
+	}
+}
+
+// events returns events filtered by band, city, country, venue, genre
+// and period of dates.
+func (s *Server) events(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	offset, limit, err := page(q.Get("offset"), q.Get("limit"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+		return
+	}
+	f := store.Filter{
+		Band:    q.Get("band"),
+		City:    q.Get("city"),
+		Country: q.Get("country"),
+		Venue:   q.Get("venue"),
+		Genre:   q.Get("genre"),
+	}
+	if v := q.Get("from"); v != "" {
+		d, err := time.Parse(dateLayout, v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_parameter", "Parameter from must be a date like 2017-05-04")
+			return
+		}
+		f.From = common.BeginOfDate(d).Unix()
+	}
+	if v := q.Get("to"); v != "" {
+		d, err := time.Parse(dateLayout, v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_parameter", "Parameter to must be a date like 2017-05-04")
+			return
+		}
+		f.To = common.EndOfDate(d).Unix()
+	}
+	events, err := s.dao.GetEvents(f, offset, limit)
+	if err != nil {
+		internalError(w, err)
+		return
+	}
+	resp := EventsResponse{
+		Events: make([]Event, 0, len(events)),
+		Offset: offset,
+		Limit:  limit,
+	}
+	for _, e := range events {
+		resp.Events = append(resp.Events, toEvent(e))
+	}
+	writeJSON(w, resp)
+}
+
+// names returns handler of names listed by the function.
+func (s *Server) names(list func(offset, limit int) ([]string, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		offset, limit, err := page(q.Get("offset"), q.Get("limit"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+			return
+		}
+		names, err := list(offset, limit)
+		if err != nil {
+			internalError(w, err)
+			return
+		}
+		if names == nil {
+			names = []string{}
+		}
+		writeJSON(w, NamesResponse{Names: names, Offset: offset, Limit: limit})
+	}
+}
+
+func (s *Server) openAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(openAPI))
+}
+
+// page returns offset and limit of page by the parameters.
+func page(offsetParam, limitParam string) (int, int, error) {
+	offset, limit := 0, defaultLimit
+	var err error
+	if offsetParam != "" {
+		if offset, err = strconv.Atoi(offsetParam); err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("Parameter offset must be a non-negative number")
+		}
+	}
+	if limitParam != "" {
+		if limit, err = strconv.Atoi(limitParam); err != nil || limit < 1 || limit > maxLimit {
+			return 0, 0, fmt.Errorf("Parameter limit must be a number from 1 to %d", maxLimit)
+		}
+	}
+	return offset, limit, nil
+}
+
+func toEvent(e store.Event) Event {
+	return Event{
+		Band:    e.Band,
+		Title:   e.Title,
+		From:    time.Unix(e.From, 0).UTC().Format(dateLayout),
+		To:      time.Unix(e.To, 0).UTC().Format(dateLayout),
+		City:    e.City,
+		Country: e.Country,
+		Venue:   e.Venue,
+		Address: e.Address,
+		Link:    e.Link,
+		Img:     e.Img,
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Error{ErrorObject{Status: status, Code: code, Message: message}})
+}
+
+// internalError logs the error, it's not returned to clients.
+func internalError(w http.ResponseWriter, err error) {
+	fmt.Fprintln(os.Stderr, err)
+	writeError(w, http.StatusInternalServerError, "internal_error", "Sorry, we have some troubles")
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/austinov/rocker-bot/config"
+	"github.com/austinov/rocker-bot/store"
+	"github.com/austinov/rocker-bot/store/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func date(year int, month time.Month, day int) int64 {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Unix()
+}
+
+func newServer(t *testing.T) *Server {
+	dao := memory.New(config.DBConfig{Type: "memory"})
+	_, err := dao.AddBandEvents([]store.Event{
+		{Band: "Metallica", Genres: []string{"Thrash Metal"}, Title: "WorldWired Tour", From: date(2017, 5, 4), To: date(2017, 5, 4),
+			City: "London", Country: "UK", Venue: "O2 Arena", Link: "http://example.com/1"},
+		{Band: "Metallica", Genres: []string{"Thrash Metal"}, Title: "Download Festival", From: date(2017, 6, 9), To: date(2017, 6, 11),
+			City: "Paris", Country: "France"},
+	})
+	assert.NoError(t, err)
+	_, err = dao.AddBandEvents([]store.Event{
+		{Band: "Slayer", Title: "Repentless Tour", From: date(2017, 5, 20), To: date(2017, 5, 20), City: "London", Country: "UK"},
+	})
+	assert.NoError(t, err)
+	return New(config.APIConfig{Enabled: true, Listen: ":0"}, dao)
+}
+
+func get(s *Server, method, url string, v interface{}) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, url, nil)
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	json.Unmarshal(w.Body.Bytes(), v)
+	return w
+}
+
+func TestEvents(t *testing.T) {
+	s := newServer(t)
+
+	var resp EventsResponse
+	w := get(s, "GET", "/events?band=Metallica", &resp)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, EventsResponse{
+		Events: []Event{
+			{Band: "Metallica", Title: "WorldWired Tour", From: "2017-05-04", To: "2017-05-04",
+				City: "London", Country: "UK", Venue: "O2 Arena", Link: "http://example.com/1"},
+			{Band: "Metallica", Title: "Download Festival", From: "2017-06-09", To: "2017-06-11",
+				City: "Paris", Country: "France"},
+		},
+		Offset: 0,
+		Limit:  defaultLimit,
+	}, resp)
+
+	// the period includes the dates
+	resp = EventsResponse{}
+	get(s, "GET", "/events?city=London&from=2017-05-04&to=2017-05-20&limit=1&offset=1", &resp)
+	assert.Equal(t, 1, resp.Limit)
+	assert.Equal(t, 1, resp.Offset)
+	if assert.Len(t, resp.Events, 1) {
+		assert.Equal(t, "Slayer", resp.Events[0].Band)
+	}
+	resp = EventsResponse{}
+	get(s, "GET", "/events?band=Metallica&to=2017-06-10", &resp)
+	assert.Len(t, resp.Events, 1)
+	resp = EventsResponse{}
+	get(s, "GET", "/events?genre=thrash", &resp)
+	assert.Len(t, resp.Events, 2)
+
+	// no events isn't an error
+	resp = EventsResponse{}
+	w = get(s, "GET", "/events?band=Anthrax", &resp)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []Event{}, resp.Events)
+}
+
+func TestNames(t *testing.T) {
+	s := newServer(t)
+
+	var resp NamesResponse
+	w := get(s, "GET", "/bands", &resp)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, NamesResponse{Names: []string{"Metallica", "Slayer"}, Limit: defaultLimit}, resp)
+
+	resp = NamesResponse{}
+	get(s, "GET", "/cities?offset=1&limit=1", &resp)
+	assert.Equal(t, NamesResponse{Names: []string{"Paris"}, Offset: 1, Limit: 1}, resp)
+
+	resp = NamesResponse{}
+	get(s, "GET", "/cities?offset=10", &resp)
+	assert.Equal(t, []string{}, resp.Names)
+}
+
+func TestErrors(t *testing.T) {
+	s := newServer(t)
+
+	cases := []struct {
+		method, url string
+		status      int
+		code        string
+	}{
+		{"GET", "/events?from=4.05.2017", http.StatusBadRequest, "invalid_parameter"},
+		{"GET", "/events?to=tomorrow", http.StatusBadRequest, "invalid_parameter"},
+		{"GET", "/events?limit=0", http.StatusBadRequest, "invalid_parameter"},
+		{"GET", "/bands?limit=101", http.StatusBadRequest, "invalid_parameter"},
+		{"GET", "/cities?offset=-1", http.StatusBadRequest, "invalid_parameter"},
+		{"POST", "/events", http.StatusMethodNotAllowed, "method_not_allowed"},
+		{"GET", "/venues", http.StatusNotFound, "not_found"},
+	}
+	for _, c := range cases {
+		var resp Error
+		w := get(s, c.method, c.url, &resp)
+		assert.Equal(t, c.status, w.Code, c.url)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"), c.url)
+		assert.Equal(t, c.status, resp.Error.Status, c.url)
+		assert.Equal(t, c.code, resp.Error.Code, c.url)
+		assert.NotEmpty(t, resp.Error.Message, c.url)
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	s := newServer(t)
+
+	var doc struct {
+		OpenAPI string                 `json:"openapi"`
+		Paths   map[string]interface{} `json:"paths"`
+	}
+	w := get(s, "GET", "/openapi.json", &doc)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+	assert.Contains(t, doc.Paths, "/events")
+	assert.Contains(t, doc.Paths, "/bands")
+	assert.Contains(t, doc.Paths, "/cities")
+}
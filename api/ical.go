@@ -0,0 +1,146 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/austinov/rocker-bot/common"
+	"github.com/austinov/rocker-bot/store"
+)
+
+const (
+	// icalPastDays is a number of days before today to keep past events in feeds
+	icalPastDays = 30
+	// icalLimit is a maximum number of events in feed
+	icalLimit = 500
+	// icalLineLength is a maximum length of line in octets, longer lines are folded
+	icalLineLength = 75
+	icalDateLayout = "20060102"
+)
+
+// ical returns handler of iCal feed of events of the band or in the city,
+// the name is taken from path like /ical/band/Metallica.ics.
+func (s *Server) ical(kind string) http.HandlerFunc {
+	prefix := "/ical/" + kind + "/"
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, prefix)
+		if !strings.HasSuffix(name, ".ics") || name == ".ics" {
+			writeError(w, http.StatusNotFound, "not_found", "Unknown path "+r.URL.Path)
+			return
+		}
+		name = strings.TrimSuffix(name, ".ics")
+
+		now := s.now()
+		f := store.Filter{From: common.BeginOfDate(now.AddDate(0, 0, -icalPastDays)).Unix()}
+		var title string
+		if kind == "band" {
+			f.Band, title = name, "Events of "+name
+		} else {
+			f.City, title = name, "Events in "+name
+		}
+		events, err := s.dao.GetEvents(f, 0, icalLimit)
+		if err != nil {
+			internalError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Write(calendar(title, events, now))
+	}
+}
+
+// calendar returns iCalendar (RFC 5545) of the events, they are all-day
+// events spanning dates of the events.
+func calendar(title string, events []store.Event, now time.Time) []byte {
+	var buf bytes.Buffer
+	writeLine(&buf, "BEGIN:VCALENDAR")
+	writeLine(&buf, "VERSION:2.0")
+	writeLine(&buf, "PRODID:-//rocker-bot//events//EN")
+	writeLine(&buf, "CALSCALE:GREGORIAN")
+	writeLine(&buf, "METHOD:PUBLISH")
+	writeLine(&buf, "X-WR-CALNAME:"+escapeText(title))
+	stamp := now.UTC().Format("20060102T150405Z")
+	for _, e := range events {
+		from := time.Unix(e.From, 0).UTC()
+		to := time.Unix(e.To, 0).UTC()
+		if to.Before(from) {
+			to = from
+		}
+		writeLine(&buf, "BEGIN:VEVENT")
+		writeLine(&buf, "UID:"+eventUID(e))
+		writeLine(&buf, "DTSTAMP:"+stamp)
+		writeLine(&buf, "DTSTART;VALUE=DATE:"+from.Format(icalDateLayout))
+		// the end date is exclusive
+		writeLine(&buf, "DTEND;VALUE=DATE:"+to.AddDate(0, 0, 1).Format(icalDateLayout))
+		writeLine(&buf, "SUMMARY:"+escapeText(summary(e)))
+		if location := location(e); location != "" {
+			writeLine(&buf, "LOCATION:"+escapeText(location))
+		}
+		if e.Link != "" {
+			writeLine(&buf, "URL:"+e.Link)
+		}
+		writeLine(&buf, "END:VEVENT")
+	}
+	writeLine(&buf, "END:VCALENDAR")
+	return buf.Bytes()
+}
+
+// eventUID returns unique id of the event, it's the same for the event
+// in all feeds to be updated by calendars.
+func eventUID(e store.Event) string {
+	h := sha1.Sum([]byte(fmt.Sprintf("%s|%s|%d|%s|%s", e.Band, e.Title, e.From, e.City, e.Venue)))
+	return fmt.Sprintf("%x@rocker-bot", h)
+}
+
+// summary returns title of the event with band's name,
+// e.g. Metallica at Download Festival.
+func summary(e store.Event) string {
+	if e.Title == "" {
+		return e.Band
+	}
+	if strings.Contains(strings.ToLower(e.Title), strings.ToLower(e.Band)) {
+		return e.Title
+	}
+	return e.Band + " at " + e.Title
+}
+
+// location returns venue, address, city and country of the event.
+func location(e store.Event) string {
+	var parts []string
+	for _, p := range []string{e.Venue, e.Address, e.City, e.Country} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// escapeText escapes value of TEXT type.
+func escapeText(text string) string {
+	return textEscaper.Replace(text)
+}
+
+// writeLine writes content line folded by icalLineLength octets,
+// the continuation lines start with space.
+func writeLine(buf *bytes.Buffer, line string) {
+	limit := icalLineLength
+	for len(line) > limit {
+		cut := limit
+		// multi-octet characters aren't split
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ")
+		line = line[cut:]
+		limit = icalLineLength - 1
+	}
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
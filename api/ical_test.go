@@ -0,0 +1,105 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/austinov/rocker-bot/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendar(t *testing.T) {
+	now := time.Date(2017, 5, 1, 12, 30, 0, 0, time.UTC)
+	events := []store.Event{
+		{Band: "Metallica", Title: "Metallica", From: date(2017, 5, 4), To: date(2017, 5, 4),
+			City: "London", Country: "UK", Venue: "O2 Arena", Address: "Peninsula Square", Link: "http://example.com/1"},
+		{Band: "Metallica", Title: "Download Festival", From: date(2017, 6, 9), To: date(2017, 6, 11), City: "Paris"},
+	}
+	cal := string(calendar("Events of Metallica", events, now))
+	lines := strings.Split(cal, "\r\n")
+	assert.Equal(t, []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//rocker-bot//events//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:Events of Metallica",
+		"BEGIN:VEVENT",
+		"UID:" + eventUID(events[0]),
+		"DTSTAMP:20170501T123000Z",
+		"DTSTART;VALUE=DATE:20170504",
+		"DTEND;VALUE=DATE:20170505",
+		"SUMMARY:Metallica",
+		`LOCATION:O2 Arena\, Peninsula Square\, London\, UK`,
+		"URL:http://example.com/1",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:" + eventUID(events[1]),
+		"DTSTAMP:20170501T123000Z",
+		"DTSTART;VALUE=DATE:20170609",
+		"DTEND;VALUE=DATE:20170612",
+		"SUMMARY:Metallica at Download Festival",
+		"LOCATION:Paris",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, lines)
+	assert.NotEqual(t, eventUID(events[0]), eventUID(events[1]))
+}
+
+func TestWriteLine(t *testing.T) {
+	var buf bytes.Buffer
+	// the 75th octet is in the middle of Ё
+	writeLine(&buf, "SUMMARY:"+strings.Repeat("a", 66)+"ЁЁЁ"+strings.Repeat("b", 80))
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n")
+	assert.Equal(t, []string{
+		"SUMMARY:" + strings.Repeat("a", 66),
+		" ЁЁЁ" + strings.Repeat("b", 68),
+		" " + strings.Repeat("b", 12),
+	}, lines)
+	for _, l := range lines {
+		assert.True(t, len(l) <= icalLineLength, l)
+	}
+
+	assert.Equal(t, `a\\b\;c\,d\ne`, escapeText("a\\b;c,d\ne"))
+}
+
+func TestICal(t *testing.T) {
+	s := newServer(t)
+	s.now = func() time.Time { return time.Date(2017, 5, 1, 0, 0, 0, 0, time.UTC) }
+
+	w := get(s, "GET", "/ical/band/Metallica.ics", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/calendar; charset=utf-8", w.Header().Get("Content-Type"))
+	body := w.Body.String()
+	assert.Contains(t, body, "X-WR-CALNAME:Events of Metallica\r\n")
+	assert.Equal(t, 2, strings.Count(body, "BEGIN:VEVENT"))
+	assert.Contains(t, body, "SUMMARY:Metallica at WorldWired Tour\r\n")
+
+	w = get(s, "GET", "/ical/city/London.ics", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	body = w.Body.String()
+	assert.Contains(t, body, "X-WR-CALNAME:Events in London\r\n")
+	assert.Equal(t, 2, strings.Count(body, "BEGIN:VEVENT"))
+	assert.Contains(t, body, "SUMMARY:Slayer at Repentless Tour\r\n")
+
+	// events which are over for long are skipped
+	s.now = func() time.Time { return time.Date(2017, 6, 20, 0, 0, 0, 0, time.UTC) }
+	body = get(s, "GET", "/ical/band/Metallica.ics", nil).Body.String()
+	assert.Equal(t, 1, strings.Count(body, "BEGIN:VEVENT"))
+
+	// escaped names are unescaped
+	w = get(s, "GET", "/ical/band/AC%2FDC.ics", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "X-WR-CALNAME:Events of AC/DC\r\n")
+
+	var resp Error
+	w = get(s, "GET", "/ical/band/Metallica", &resp)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "not_found", resp.Error.Code)
+	w = get(s, "GET", "/ical/city/.ics", &resp)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
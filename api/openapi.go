@@ -0,0 +1,172 @@
+package api
+
+// openAPI is an OpenAPI description of the API served at /openapi.json.
+const openAPI = `{
+  "openapi": "3.0.3",
+  "info": {
+    "title": "rocker-bot events API",
+    "description": "Events of rock and metal bands collected by rocker-bot.",
+    "version": "1.0.0"
+  },
+  "paths": {
+    "/events": {
+      "get": {
+        "summary": "List events",
+        "description": "Returns events filtered by band, city, country, venue, genre and dates ordered by date.",
+        "parameters": [
+          {"name": "band", "in": "query", "description": "Name of band", "schema": {"type": "string"}},
+          {"name": "city", "in": "query", "description": "Name of city", "schema": {"type": "string"}},
+          {"name": "country", "in": "query", "description": "Name of country", "schema": {"type": "string"}},
+          {"name": "venue", "in": "query", "description": "Name of venue", "schema": {"type": "string"}},
+          {"name": "genre", "in": "query", "description": "Part of genre's name, e.g. doom", "schema": {"type": "string"}},
+          {"name": "from", "in": "query", "description": "Events start on this date or later", "schema": {"type": "string", "format": "date"}},
+          {"name": "to", "in": "query", "description": "Events end on this date or earlier", "schema": {"type": "string", "format": "date"}},
+          {"$ref": "#/components/parameters/offset"},
+          {"$ref": "#/components/parameters/limit"}
+        ],
+        "responses": {
+          "200": {
+            "description": "Page of events",
+            "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Events"}}}
+          },
+          "400": {"$ref": "#/components/responses/Error"},
+          "500": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/bands": {
+      "get": {
+        "summary": "List bands",
+        "description": "Returns names of bands ordered by name.",
+        "parameters": [
+          {"$ref": "#/components/parameters/offset"},
+          {"$ref": "#/components/parameters/limit"}
+        ],
+        "responses": {
+          "200": {
+            "description": "Page of names",
+            "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Names"}}}
+          },
+          "400": {"$ref": "#/components/responses/Error"},
+          "500": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/cities": {
+      "get": {
+        "summary": "List cities",
+        "description": "Returns names of cities ordered by name.",
+        "parameters": [
+          {"$ref": "#/components/parameters/offset"},
+          {"$ref": "#/components/parameters/limit"}
+        ],
+        "responses": {
+          "200": {
+            "description": "Page of names",
+            "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Names"}}}
+          },
+          "400": {"$ref": "#/components/responses/Error"},
+          "500": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/ical/band/{name}.ics": {
+      "get": {
+        "summary": "iCal feed of band",
+        "description": "Returns iCalendar of events of the band as all-day events, past events are kept for 30 days.",
+        "parameters": [
+          {"name": "name", "in": "path", "required": true, "description": "Name of band", "schema": {"type": "string"}}
+        ],
+        "responses": {
+          "200": {
+            "description": "Calendar of events",
+            "content": {"text/calendar": {"schema": {"type": "string"}}}
+          },
+          "404": {"$ref": "#/components/responses/Error"},
+          "500": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/ical/city/{name}.ics": {
+      "get": {
+        "summary": "iCal feed of city",
+        "description": "Returns iCalendar of events in the city as all-day events, past events are kept for 30 days.",
+        "parameters": [
+          {"name": "name", "in": "path", "required": true, "description": "Name of city", "schema": {"type": "string"}}
+        ],
+        "responses": {
+          "200": {
+            "description": "Calendar of events",
+            "content": {"text/calendar": {"schema": {"type": "string"}}}
+          },
+          "404": {"$ref": "#/components/responses/Error"},
+          "500": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    }
+  },
+  "components": {
+    "parameters": {
+      "offset": {"name": "offset", "in": "query", "description": "Number of skipped items", "schema": {"type": "integer", "minimum": 0, "default": 0}},
+      "limit": {"name": "limit", "in": "query", "description": "Maximum number of items", "schema": {"type": "integer", "minimum": 1, "maximum": 100, "default": 20}}
+    },
+    "responses": {
+      "Error": {
+        "description": "Error",
+        "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}
+      }
+    },
+    "schemas": {
+      "Event": {
+        "type": "object",
+        "required": ["band", "title", "from", "to", "city"],
+        "properties": {
+          "band": {"type": "string"},
+          "title": {"type": "string"},
+          "from": {"type": "string", "format": "date"},
+          "to": {"type": "string", "format": "date"},
+          "city": {"type": "string"},
+          "country": {"type": "string"},
+          "venue": {"type": "string"},
+          "address": {"type": "string"},
+          "link": {"type": "string", "format": "uri"},
+          "img": {"type": "string", "format": "uri"}
+        }
+      },
+      "Events": {
+        "type": "object",
+        "required": ["events", "offset", "limit"],
+        "properties": {
+          "events": {"type": "array", "items": {"$ref": "#/components/schemas/Event"}},
+          "offset": {"type": "integer"},
+          "limit": {"type": "integer"}
+        }
+      },
+      "Names": {
+        "type": "object",
+        "required": ["names", "offset", "limit"],
+        "properties": {
+          "names": {"type": "array", "items": {"type": "string"}},
+          "offset": {"type": "integer"},
+          "limit": {"type": "integer"}
+        }
+      },
+      "Error": {
+        "type": "object",
+        "required": ["error"],
+        "properties": {
+          "error": {
+            "type": "object",
+            "required": ["status", "code", "message"],
+            "properties": {
+              "status": {"type": "integer", "description": "HTTP status code"},
+              "code": {"type": "string", "enum": ["invalid_parameter", "not_found", "method_not_allowed", "internal_error"]},
+              "message": {"type": "string"}
+            }
+          }
+        }
+      }
+    }
+  }
+}
+`
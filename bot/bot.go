@@ -2,11 +2,10 @@ package bot
 
 import (
 	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"io/ioutil"
 	"log"
-	"net/http"
 	"os"
 	"strings"
 	"sync"
@@ -17,100 +16,141 @@ import (
 	"github.com/austinov/rocker-bot/common"
 	"github.com/austinov/rocker-bot/config"
 	"github.com/austinov/rocker-bot/store"
-
-	"golang.org/x/net/websocket"
 )
 
 const (
-	apiURL               = "https://api.slack.com/"
-	startRtmURL          = "https://slack.com/api/rtm.start?token=%s"
+	// attemptsToReceiveMsg is a number of failed receives to reconnect
 	attemptsToReceiveMsg = 3
+	// attemptsToSendReply is a number of failed sends to drop the reply
+	// if it's resent by the reply policy
+	attemptsToSendReply = 5
+)
+
+// reply policies, they are used if reply is failed to be sent
+const (
+	// resendReply resends the reply with backoff, e.g. until the bot is reconnected
+	resendReply = "resend"
+	// dropReply drops the reply
+	dropReply = "drop"
 )
 
+var errNotConnected = errors.New("bot is not connected")
+
 type Bot struct {
-	cfg config.BotConfig
-	dao store.Dao
-	ws  *websocket.Conn
-	id  string
+	cfg       config.BotConfig
+	dao       store.Dao
+	t         Transport
+	connected int32 // it's 1 while the bot is connected, it's changed atomically
 }
 
-func New(cfg config.BotConfig, dao store.Dao) *Bot {
+// New returns bot which receives commands and sends replies by the transport.
+func New(cfg config.BotConfig, dao store.Dao, t Transport) *Bot {
 	if cfg.NumHandlers <= 0 {
 		cfg.NumHandlers = 1
 	}
 	if cfg.NumSenders <= 0 {
 		cfg.NumSenders = 1
 	}
-	return &Bot{
+	if cfg.ReplyPolicy == "" {
+		cfg.ReplyPolicy = resendReply
+	}
+	b := &Bot{
 		cfg: cfg,
 		dao: dao,
+		t:   t,
 	}
+	if i, ok := t.(Interactive); ok {
+		i.SetReplier(b)
+	}
+	return b
 }
 
-func (b *Bot) Start() {
+// Start runs the bot and blocks until ctx is done. Then the bot stops receiving
+// messages, processes the received ones, sends replies and closes the connection.
+func (b *Bot) Start(ctx context.Context) {
 	log.Println("Start bot.")
 	if err := b.connect(); err != nil {
-		log.Fatal(err)
+		fmt.Fprintf(os.Stderr, "connect failed with %#v\n", err)
+		if !b.reconnect(ctx) {
+			log.Println("Bot stopped.")
+			return
+		}
 	}
 
 	var wg sync.WaitGroup
 
 	wg.Add(1)
 	messages := make(chan interface{}, b.cfg.NumHandlers)
-	common.RunWorkers(&wg, nil, messages, 1, b.pollMessages)
+	common.RunWorkers(ctx, &wg, nil, messages, 1, b.pollMessages)
 	wg.Add(1)
 	replies := make(chan interface{}, b.cfg.NumSenders)
-	common.RunWorkers(&wg, messages, replies, b.cfg.NumHandlers, b.processMessages)
+	common.RunWorkers(ctx, &wg, messages, replies, b.cfg.NumHandlers, b.processMessages)
 	wg.Add(1)
-	common.RunWorkers(&wg, replies, nil, b.cfg.NumSenders, b.processReplies)
+	common.RunWorkers(ctx, &wg, replies, nil, b.cfg.NumSenders, b.processReplies)
+	wg.Add(1)
+	common.RunWorkers(ctx, &wg, nil, nil, 1, b.runWatches)
 
 	wg.Wait()
-}
 
-func (b *Bot) connect() error {
-	resp, err := http.Get(fmt.Sprintf(startRtmURL, b.cfg.Token))
-	if err != nil {
-		return err
+	atomic.StoreInt32(&b.connected, 0)
+	if err := b.t.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "close connection failed with %#v\n", err)
 	}
-	defer resp.Body.Close()
+	log.Println("Bot stopped.")
+}
 
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("Start RTM request failed with code %d", resp.StatusCode)
-	}
-	body, err := ioutil.ReadAll(resp.Body)
+// connect connects the bot to the chat.
+func (b *Bot) connect() error {
+	id, err := b.t.Connect()
 	if err != nil {
 		return err
 	}
-	var respRtm ResponseRtmStart
-	if err = json.Unmarshal(body, &respRtm); err != nil {
-		return err
-	}
-
-	if !respRtm.Ok {
-		return fmt.Errorf("Slack error: %s", respRtm.Error)
-	}
+	log.Printf("Bot is connected as %s.\n", id)
+	atomic.StoreInt32(&b.connected, 1)
+	return nil
+}
 
-	ws, err := websocket.Dial(respRtm.Url, "", apiURL)
-	if err != nil {
-		return err
+// reconnect connects the bot to the chat again with backoff until it succeeds
+// or ctx is done. Replies are not sent while the bot is reconnecting.
+func (b *Bot) reconnect(ctx context.Context) bool {
+	atomic.StoreInt32(&b.connected, 0)
+	eb := backoff.NewExpBackoff()
+	for {
+		select {
+		case <-eb.Delay():
+		case <-ctx.Done():
+			return false
+		}
+		log.Printf("Reconnect bot, attempt %d.\n", eb.Attempts())
+		if err := b.connect(); err != nil {
+			fmt.Fprintf(os.Stderr, "reconnect failed with %#v\n", err)
+		} else {
+			return true
+		}
 	}
-
-	b.ws = ws
-	b.id = "<@" + respRtm.Self.Id + ">"
-
-	return nil
 }
 
-func (b *Bot) pollMessages(ignore <-chan interface{}, outMessages chan<- interface{}) {
+func (b *Bot) pollMessages(ctx context.Context, ignore <-chan interface{}, outMessages chan<- interface{}) {
 	eb := backoff.NewExpBackoff()
 	for {
-		var m Message
-		if err := websocket.JSON.Receive(b.ws, &m); err != nil {
-			fmt.Fprintf(os.Stderr, "receive message from socket failed with %#v\n", err)
+		m, err := b.receive(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "receive message failed with %#v\n", err)
 			if eb.Attempts() >= uint64(attemptsToReceiveMsg) {
-				os.Exit(1)
+				if !b.reconnect(ctx) {
+					return
+				}
+				eb.Reset()
+			} else {
+				select {
+				case <-eb.Delay():
+				case <-ctx.Done():
+					return
+				}
 			}
-			<-eb.Delay()
 		} else {
 			eb.Reset()
 			outMessages <- m
@@ -118,130 +158,387 @@ func (b *Bot) pollMessages(ignore <-chan interface{}, outMessages chan<- interfa
 	}
 }
 
-func (b *Bot) processMessages(inMessages <-chan interface{}, outReplies chan<- interface{}) {
+// receive returns the next message or error if ctx is done before the message
+// is received. The connection is not closed, so replies may be sent.
+func (b *Bot) receive(ctx context.Context) (Message, error) {
+	type result struct {
+		m   Message
+		err error
+	}
+	received := make(chan result, 1)
+	go func() {
+		m, err := b.t.Receive()
+		received <- result{m, err}
+	}()
+	select {
+	case r := <-received:
+		return r.m, r.err
+	case <-ctx.Done():
+		return Message{}, ctx.Err()
+	}
+}
+
+// processMessages is run by NumHandlers workers, the received messages
+// are processed until messages channel is closed, so replies channel
+// is closed after the last reply.
+func (b *Bot) processMessages(ctx context.Context, inMessages <-chan interface{}, outReplies chan<- interface{}) {
 	for e := range inMessages {
 		message, ok := e.(Message)
 		if !ok {
 			log.Fatalln("Illegal type of argument, expected Message")
 		}
-		go b.processMessage(message, outReplies)
+		b.processMessage(message, outReplies)
 	}
 }
 
 func (b *Bot) processMessage(msg Message, outReplies chan<- interface{}) {
-	if msg.Type == "message" && strings.HasPrefix(msg.Text, b.id) {
-		query := Parse(msg.Text)
-		if query.IsValid() && query.Command == "events" {
-			msg.Text = b.calendarHandler(query)
-		} else {
-			msg.Text = b.helpHandler()
-		}
-		outReplies <- msg
+	outReplies <- b.Reply(msg)
+}
+
+// Reply returns reply to the command. The reply is posted in thread of the
+// command if the command is in thread or the reply is long and the long
+// replies are moved into threads.
+func (b *Bot) Reply(msg Message) Message {
+	query := ParseCommand(msg.Text)
+	reply := Message{Channel: msg.Channel}
+	switch {
+	case query.IsValid() && query.Command == "events":
+		reply = b.calendarHandler(query, 0)
+		reply.Channel = msg.Channel
+	case query.IsValid() && query.Command == "follow":
+		reply.Text = b.followHandler(msg.User, msg.Channel, query)
+	case query.IsValid() && query.Command == "unfollow":
+		reply.Text = b.unfollowHandler(msg.User, msg.Channel, query)
+	case query.IsValid() && query.Command == "following":
+		reply.Text = b.followingHandler(msg.User, msg.Channel)
+	case query.IsValid() && query.Command == "watch":
+		reply.Text = b.watchHandler(msg.Channel, query, time.Now())
+	case query.IsValid() && query.Command == "unwatch":
+		reply.Text = b.unwatchHandler(msg.Channel, query)
+	case query.IsValid() && query.Command == "watching":
+		reply.Text = b.watchingHandler(msg.Channel)
+	case query.IsValid() && query.Command == "ical":
+		reply.Text = b.icalHandler(query)
+	default:
+		reply.Text = b.helpHandler()
 	}
+	reply.ThreadId = b.replyThread(msg, reply)
+	return reply
 }
 
-// sequentially increased message counter
-var messageId uint64
+// Calendar returns page of events of the query starting from offset.
+func (b *Bot) Calendar(query Query, offset int) Message {
+	return b.calendarHandler(query, offset)
+}
 
-func (b *Bot) processReplies(inReplies <-chan interface{}, ignore chan<- interface{}) {
+// replyThread returns id of thread to post the reply to the command.
+// It's empty to post the reply in the channel.
+func (b *Bot) replyThread(msg, reply Message) string {
+	if msg.ThreadId != "" {
+		return msg.ThreadId
+	}
+	if b.cfg.LongReplyLines > 0 && !msg.Direct &&
+		strings.Count(reply.Text, "\n") >= b.cfg.LongReplyLines {
+		return msg.Id
+	}
+	return ""
+}
+
+func (b *Bot) processReplies(ctx context.Context, inReplies <-chan interface{}, ignore chan<- interface{}) {
 	for e := range inReplies {
 		reply, ok := e.(Message)
 		if !ok {
 			log.Fatalln("Illegal type of argument, expected Message")
 		}
-		reply.Id = atomic.AddUint64(&messageId, 1)
-		if err := websocket.JSON.Send(b.ws, reply); err != nil {
-			fmt.Fprintf(os.Stderr, "send reply failed with %#v\n", err)
+		b.sendReply(ctx, reply)
+	}
+}
+
+// sendReply sends the reply, failed reply is resent or dropped by the reply policy.
+// It isn't resent after ctx is done.
+func (b *Bot) sendReply(ctx context.Context, reply Message) {
+	eb := backoff.NewExpBackoff()
+	for {
+		err := b.send(reply)
+		if err == nil {
+			return
+		}
+		fmt.Fprintf(os.Stderr, "send reply failed with %#v\n", err)
+		if b.cfg.ReplyPolicy == dropReply || eb.Attempts() >= uint64(attemptsToSendReply) {
+			fmt.Fprintf(os.Stderr, "reply to channel %s is dropped\n", reply.Channel)
+			return
+		}
+		select {
+		case <-eb.Delay():
+		case <-ctx.Done():
+			fmt.Fprintf(os.Stderr, "reply to channel %s is dropped\n", reply.Channel)
+			return
 		}
 	}
 }
 
+// send sends the message to the chat transport, it may be called from several go-routines.
+func (b *Bot) send(msg Message) error {
+	if atomic.LoadInt32(&b.connected) == 0 {
+		return errNotConnected
+	}
+	return b.t.Send(msg)
+}
+
 // helpHandler returns a reply containing help text.
 func (b *Bot) helpHandler() string {
+	commands := []struct {
+		command, help string
+	}{
+		{"events of Metallica", "list events of Metallica"},
+		{"events in Paris    ", "list events in Paris"},
+		{"events of Slayer in country Germany", "list events of band in country"},
+		{"events at venue Tavastia", "list events at the venue"},
+		{"events of genre doom in Berlin", "list events of bands of the genre in city"},
+		{"events in London at 27 May 2017", "list events in city at the date (date format may be also dd.MM.yyyy or dd/MM/yyyy)"},
+		{"events of System of a Down in Dresden since 1 Jan 2017", "list events of band in city since the date"},
+		{"events in Helsinki till 1 Jan 2017", "list events in city till the date"},
+		{"events in St Petersburg since 15 Dec 2016 till 1 Jan 2017", "list events in city since/till dates"},
+		{"events of Aerosmith for 15 Dec 2016 and 13 Jan 2017", "list events of band for period"},
+		{"follow Metallica", "post new events of band in the channel"},
+		{"unfollow Metallica", "stop posting new events of band"},
+		{"following", "list bands you follow in the channel"},
+		{"watch Helsinki", "post digest of the week's events in city every Monday"},
+		{"unwatch Helsinki", "stop posting digest of events in city"},
+		{"watching", "list cities watched in the channel"},
+		{"ical of Metallica", "link to subscribe to events of band (or in city: ical in Paris) in your calendar"},
+	}
 	buffer := bytes.NewBufferString("Please, use commands like the follow:\n")
-	buffer.WriteString(fmt.Sprintf(">%s events of Metallica - list events of Metallica\n", b.id))
-	buffer.WriteString(fmt.Sprintf(">%s events in Paris     - list events in Paris\n", b.id))
-	buffer.WriteString(fmt.Sprintf(">%s events in London at 27 May 2017 - list events in city at the date (date format may be also dd.MM.yyyy or dd/MM/yyyy)\n", b.id))
-	buffer.WriteString(fmt.Sprintf(">%s events of System of a Down in Dresden since 1 Jan 2017 - list events of band in city since the date\n", b.id))
-	buffer.WriteString(fmt.Sprintf(">%s events in Helsinki till 1 Jan 2017 - list events in city till the date\n", b.id))
-	buffer.WriteString(fmt.Sprintf(">%s events in St Petersburg since 15 Dec 2016 till 1 Jan 2017 - list events in city since/till dates\n", b.id))
-	buffer.WriteString(fmt.Sprintf(">%s events of Aerosmith for 15 Dec 2016 and 13 Jan 2017 - list events of band for period\n", b.id))
+	for _, c := range commands {
+		buffer.WriteString(b.t.Quote(b.t.Command(c.command)+" - "+c.help) + "\n")
+	}
+	if d, ok := b.t.(DirectCommander); ok {
+		buffer.WriteString("In direct messages the commands may be used without mention of the bot, e.g. " +
+			d.DirectCommand("events of Metallica") + ".\n")
+	}
 	return buffer.String()
 }
 
-// calendarHandler returns calendar for the band.
-func (b *Bot) calendarHandler(query Query) string {
-	if query.To == 0 {
-		query.To = time.Now().AddDate(10, 0, 0).Unix()
+// calendarHandler returns calendar for the band starting from offset.
+// The reply has page of events, so the transport may render it by rich
+// elements of the chat, e.g. with buttons to load the next events.
+func (b *Bot) calendarHandler(query Query, offset int) Message {
+	limit := b.pageSize()
+	events, err := b.getEvents(query, offset, limit)
+	if err == nil && len(events) == 0 {
+		// band or city may be misspelled, try to find the closest names
+		var corrected Query
+		var suggestions []Query
+		corrected, suggestions, err = b.correctQuery(query)
+		if err == nil && len(suggestions) > 0 {
+			return Message{Text: b.formatSuggestions(query, suggestions)}
+		}
+		if err == nil && corrected != query {
+			query = corrected
+			events, err = b.getEvents(query, offset, limit)
+		}
 	}
-	offset, limit := 0, 42
-	events, err := b.dao.GetEvents(query.Band, query.City, query.From, query.To, offset, limit)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		return "Sorry, we have some troubles"
+		return Message{Text: "Sorry, we have some troubles"}
 	} else {
-		l := len(events)
-		if l == 0 {
-			return formatHeader(query, true)
-		} else {
-			out := formatHeader(query, false)
-			for _, event := range events {
-				out += formatEvent(event)
+		page := &Page{
+			Query:  query,
+			Offset: offset,
+			Limit:  limit,
+			Header: b.formatHeader(query, len(events) == 0),
+			Events: events,
+		}
+		text := page.Header
+		for _, event := range events {
+			text += b.formatEvent(event)
+		}
+		if page.More() {
+			page.Footer = b.formatFooter(query, events[len(events)-1])
+			text += page.Footer
+		}
+		return Message{Text: text, Page: page}
+	}
+}
+
+// pageSize returns a maximum number of events in reply.
+func (b *Bot) pageSize() int {
+	if p, ok := b.t.(Pager); ok && p.PageSize() > 0 {
+		return p.PageSize()
+	}
+	return eventsLimit
+}
+
+func (b *Bot) getEvents(query Query, offset, limit int) ([]store.Event, error) {
+	return b.dao.GetEvents(store.Filter{
+		Band:    query.Band,
+		City:    query.City,
+		Country: query.Country,
+		Venue:   query.Venue,
+		Genre:   query.Genre,
+		From:    query.From,
+		To:      query.To,
+	}, offset, limit)
+}
+
+// eventsLimit is a maximum number of events in reply
+const eventsLimit = 42
+
+const (
+	// maxSuggestions is a maximum number of names in "did you mean" reply
+	maxSuggestions = 5
+	// confidentSimilarity is a minimal similarity of name to use it instead of requested
+	confidentSimilarity = 0.6
+	// confidentGap is a minimal gap between the most similar name and the next one
+	// to use the most similar name instead of requested
+	confidentGap = 0.15
+)
+
+// correctQuery returns query with band and city replaced by the confidently
+// matched names or queries with suggested names if there is no confident match.
+func (b *Bot) correctQuery(q Query) (Query, []Query, error) {
+	if q.Band != "" {
+		matches, err := b.dao.FindBands(q.Band, maxSuggestions)
+		if err != nil {
+			return q, nil, err
+		}
+		name, names := closestName(matches)
+		if name == "" && len(names) > 0 {
+			suggestions := make([]Query, len(names))
+			for i, n := range names {
+				suggestions[i] = q
+				suggestions[i].Band = n
 			}
-			if l >= limit {
-				out += formatFooter(b.id, query, events[l-1])
+			return q, suggestions, nil
+		}
+		if name != "" {
+			q.Band = name
+		}
+	}
+	if q.City != "" {
+		matches, err := b.dao.FindCities(q.City, maxSuggestions)
+		if err != nil {
+			return q, nil, err
+		}
+		name, names := closestName(matches)
+		if name == "" && len(names) > 0 {
+			suggestions := make([]Query, len(names))
+			for i, n := range names {
+				suggestions[i] = q
+				suggestions[i].City = n
 			}
-			return out
+			return q, suggestions, nil
+		}
+		if name != "" {
+			q.City = name
 		}
 	}
+	return q, nil, nil
 }
 
-func formatHeader(q Query, empty bool) string {
-	var band, city string
+// closestName returns the name which confidently matches the requested one
+// or names to suggest if there is no confident match.
+func closestName(matches []store.Match) (string, []string) {
+	if len(matches) == 0 {
+		return "", nil
+	}
+	best := matches[0]
+	if best.Similarity >= confidentSimilarity &&
+		(len(matches) == 1 || best.Similarity-matches[1].Similarity >= confidentGap) {
+		return best.Name, nil
+	}
+	names := make([]string, len(matches))
+	for i, m := range matches {
+		names[i] = m.Name
+	}
+	return "", names
+}
+
+func (b *Bot) formatHeader(q Query, empty bool) string {
+	var band, city, country, venue string
 	if q.Band != "" {
-		band = fmt.Sprintf(" of *%s*", q.Band)
+		band = fmt.Sprintf(" of %s", b.t.Bold(q.Band))
+	} else if q.Genre != "" {
+		band = fmt.Sprintf(" of genre %s", b.t.Bold(q.Genre))
+	}
+	if q.Venue != "" {
+		venue = fmt.Sprintf(" at %s", b.t.Italic(q.Venue))
 	}
 	if q.City != "" {
-		city = fmt.Sprintf(" in _%s_", q.City)
+		city = fmt.Sprintf(" in %s", b.t.Italic(q.City))
+	}
+	if q.Country != "" {
+		country = fmt.Sprintf(" in %s", b.t.Italic(q.Country))
 	}
 	if empty {
-		return fmt.Sprintf("We have no more info about events%s%s.", band, city)
+		return fmt.Sprintf("We have no more info about events%s%s%s%s.", band, venue, city, country)
 	} else {
-		return fmt.Sprintf("We known about the following events%s%s:\n", band, city)
+		return fmt.Sprintf("We known about the following events%s%s%s%s:\n", band, venue, city, country)
 	}
 }
 
-func formatEvent(e store.Event) string {
-	fd := func(sec int64) string {
-		return time.Unix(sec, 0).Format("2 Jan 2006")
-	}
-	var dates, location, link string
-	if e.From != e.To {
-		dates = fmt.Sprintf("%s - %s", fd(e.From), fd(e.To))
-	} else {
-		dates = fmt.Sprintf("%s", fd(e.From))
-	}
+func (b *Bot) formatEvent(e store.Event) string {
+	var location, link string
 	if e.City != "" && e.Venue != "" {
-		location = fmt.Sprintf("(%s - _%s_)", e.City, e.Venue)
+		location = fmt.Sprintf("(%s - %s)", e.City, b.t.Italic(e.Venue))
 	} else if e.City != "" {
 		location = fmt.Sprintf("(%s)", e.City)
 	} else if e.Venue != "" {
-		location = fmt.Sprintf("(_%s_)", e.Venue)
+		location = fmt.Sprintf("(%s)", b.t.Italic(e.Venue))
 	}
 	if e.Link != "" {
 		link = fmt.Sprintf("- %s", e.Link)
 	}
-	return fmt.Sprintf(">%s, *%s* %s %s\n", dates, e.Title, location, link)
+	return b.t.Quote(fmt.Sprintf("%s, %s %s %s", FormatDates(e), b.t.Bold(e.Title), location, link)) + "\n"
+}
+
+// FormatDates returns date of the event or its period.
+func FormatDates(e store.Event) string {
+	fd := func(sec int64) string {
+		return time.Unix(sec, 0).Format("2 Jan 2006")
+	}
+	if e.From != e.To {
+		return fmt.Sprintf("%s - %s", fd(e.From), fd(e.To))
+	}
+	return fd(e.From)
+}
+
+func (b *Bot) formatFooter(q Query, e store.Event) string {
+	q.From, q.To = e.From, 0
+	return fmt.Sprintf("To load next portion of events you may use:\n%s", b.t.Quote(b.t.Command(formatCommand(q))))
+}
+
+func (b *Bot) formatSuggestions(q Query, suggestions []Query) string {
+	out := b.formatHeader(q, true) + " Did you mean:\n"
+	for _, s := range suggestions {
+		out += b.t.Quote(b.t.Command(formatCommand(s))) + "\n"
+	}
+	return out
 }
 
-func formatFooter(id string, q Query, e store.Event) string {
-	var band, city string
+// formatCommand returns text of command to request events by the query.
+func formatCommand(q Query) string {
+	var band, genre, venue, city, country, since, till string
 	if q.Band != "" {
 		band = fmt.Sprintf(" of %s", q.Band)
 	}
+	if q.Genre != "" {
+		genre = fmt.Sprintf(" of genre %s", q.Genre)
+	}
+	if q.Venue != "" {
+		venue = fmt.Sprintf(" at venue %s", q.Venue)
+	}
 	if q.City != "" {
 		city = fmt.Sprintf(" in %s", q.City)
 	}
-	since := time.Unix(e.From, 0).Format("02 Jan 2006")
-	return fmt.Sprintf("To load next portion of events you may use:\n>%s events%s%s since %s", id, band, city, since)
+	if q.Country != "" {
+		country = fmt.Sprintf(" in country %s", q.Country)
+	}
+	if q.From != 0 {
+		since = " since " + time.Unix(q.From, 0).Format("02 Jan 2006")
+	}
+	if q.To != 0 {
+		till = " till " + time.Unix(q.To, 0).Format("02 Jan 2006")
+	}
+	return fmt.Sprintf("events%s%s%s%s%s%s%s", band, genre, venue, city, country, since, till)
 }
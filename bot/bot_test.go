@@ -0,0 +1,455 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/austinov/rocker-bot/config"
+	"github.com/austinov/rocker-bot/store"
+	"github.com/austinov/rocker-bot/store/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendarHandler(t *testing.T) {
+	dao := memory.New(config.DBConfig{Type: "memory"})
+	defer dao.Close()
+	dao.AddBandEvents([]store.Event{
+		{Band: "Metallica", Title: "Metallica", From: 1493856000, To: 1493856000, City: "Paris", Venue: "Stade de France"},
+	})
+	b := New(config.BotConfig{Token: "xxx"}, dao, &fakeTransport{})
+
+	assert.Equal(t,
+		"We known about the following events of *Metallica*:\n>4 May 2017, *Metallica* (Paris - _Stade de France_) \n",
+		b.calendarHandler(Query{Command: "events", Band: "Metallica"}, 0).Text)
+	assert.Equal(t,
+		"We have no more info about events of *Metallica* in _London_.",
+		b.calendarHandler(Query{Command: "events", Band: "Metallica", City: "London"}, 0).Text)
+}
+
+func TestCalendarHandlerFuzzy(t *testing.T) {
+	dao := memory.New(config.DBConfig{Type: "memory"})
+	defer dao.Close()
+	dao.AddBandEvents([]store.Event{
+		{Band: "Metallica", Title: "Metallica", From: 1493856000, To: 1493856000, City: "Paris"},
+	})
+	dao.AddBandEvents([]store.Event{
+		{Band: "Motörhead", Title: "Motörhead", From: 1493856000, To: 1493856000, City: "Paris"},
+	})
+	dao.AddBandEvents([]store.Event{
+		{Band: "Motorhead", Title: "Motorhead", From: 1493856000, To: 1493856000, City: "Paris"},
+	})
+	b := New(config.BotConfig{Token: "xxx"}, dao, &fakeTransport{})
+
+	// confident match is used instead of misspelled name
+	assert.Equal(t,
+		"We known about the following events of *Metallica*:\n>4 May 2017, *Metallica* (Paris) \n",
+		b.calendarHandler(Query{Command: "events", Band: "metalica"}, 0).Text)
+	// several matches are suggested
+	assert.Equal(t,
+		"We have no more info about events of *Motorhed* in _Paris_. Did you mean:\n"+
+			"><@bot> events of Motorhead in Paris\n"+
+			"><@bot> events of Motörhead in Paris\n",
+		b.calendarHandler(Query{Command: "events", Band: "Motorhed", City: "Paris"}, 0).Text)
+}
+
+func TestFollowHandlers(t *testing.T) {
+	dao := memory.New(config.DBConfig{Type: "memory"})
+	defer dao.Close()
+	dao.AddBandEvents([]store.Event{
+		{Band: "Metallica", Title: "Metallica", From: 1493856000, To: 1493856000, City: "Paris"},
+	})
+	b := New(config.BotConfig{Token: "xxx"}, dao, &fakeTransport{})
+
+	assert.Equal(t,
+		"You don't follow any band in this channel, you may use:\n><@bot> follow Metallica",
+		b.followingHandler("U1", "C1"))
+	// misspelled name is corrected
+	assert.Equal(t,
+		"You follow *Metallica* now, new events will be posted in this channel.",
+		b.followHandler("U1", "C1", Query{Command: "follow", Band: "metalica"}))
+	// band without events
+	assert.Equal(t,
+		"You follow *Slayer* now, new events will be posted in this channel.",
+		b.followHandler("U1", "C1", Query{Command: "follow", Band: "Slayer"}))
+	assert.Equal(t,
+		"You follow the following bands in this channel:\n>Metallica\n>Slayer\n",
+		b.followingHandler("U1", "C1"))
+	assert.Equal(t,
+		"You don't follow *Slayer* anymore.",
+		b.unfollowHandler("U1", "C1", Query{Command: "unfollow", Band: "Slayer"}))
+	assert.Equal(t,
+		"You follow the following bands in this channel:\n>Metallica\n",
+		b.followingHandler("U1", "C1"))
+}
+
+func TestNewEventsMessages(t *testing.T) {
+	dao := memory.New(config.DBConfig{Type: "memory"})
+	defer dao.Close()
+	dao.Follow(store.Subscription{User: "U1", Channel: "C1", Band: "Metallica"})
+	dao.Follow(store.Subscription{User: "U2", Channel: "C1", Band: "Metallica"})
+	dao.Follow(store.Subscription{User: "U1", Channel: "C2", Band: "Metallica"})
+	b := New(config.BotConfig{Token: "xxx"}, dao, &fakeTransport{})
+
+	now := time.Unix(1493856000, 0)
+	messages := b.newEventsMessages([]store.Event{
+		{Band: "Metallica", Title: "Metallica", From: 1493856000, To: 1493856000, City: "Paris"},
+		{Band: "Metallica", Title: "Metallica", From: 1400000000, To: 1400000000, City: "London"},
+		{Band: "Slayer", Title: "Slayer", From: 1493856000, To: 1493856000, City: "Paris"},
+	}, now)
+	assert.Equal(t, []Message{
+		{Channel: "C1", Text: "<@U1> <@U2> New events of *Metallica*:\n>4 May 2017, *Metallica* (Paris) \n"},
+		{Channel: "C2", Text: "<@U1> New events of *Metallica*:\n>4 May 2017, *Metallica* (Paris) \n"},
+	}, messages)
+
+	// failed notification is resent
+	ft := &fakeTransport{sendErrs: 1}
+	b = New(config.BotConfig{Token: "xxx"}, dao, ft)
+	assert.NoError(t, b.connect())
+	b.NotifyNewEvents(context.Background(), []store.Event{
+		{Band: "Metallica", Title: "Metallica", From: 4102444800, To: 4102444800, City: "Paris"},
+	})
+	assert.Equal(t, 3, ft.sends)
+	assert.Len(t, ft.sent, 2)
+}
+
+func TestNextDigest(t *testing.T) {
+	// Thursday
+	assert.Equal(t,
+		time.Date(2017, 5, 8, digestHour, 0, 0, 0, time.Local),
+		nextDigest(time.Date(2017, 5, 4, 12, 0, 0, 0, time.Local)))
+	// Monday before the digest
+	assert.Equal(t,
+		time.Date(2017, 5, 8, digestHour, 0, 0, 0, time.Local),
+		nextDigest(time.Date(2017, 5, 8, 1, 0, 0, 0, time.Local)))
+	// Monday at the digest
+	assert.Equal(t,
+		time.Date(2017, 5, 15, digestHour, 0, 0, 0, time.Local),
+		nextDigest(time.Date(2017, 5, 8, digestHour, 0, 0, 0, time.Local)))
+}
+
+func TestWatchHandlers(t *testing.T) {
+	dao := memory.New(config.DBConfig{Type: "memory"})
+	defer dao.Close()
+	from := time.Date(2017, 5, 9, 0, 0, 0, 0, time.Local).Unix()
+	dao.AddBandEvents([]store.Event{
+		{Band: "Metallica", Title: "Metallica", From: from, To: from, City: "Helsinki"},
+		{Band: "Metallica", Title: "Metallica", From: from + 7*24*3600, To: from + 7*24*3600, City: "Helsinki"},
+	})
+	b := New(config.BotConfig{Token: "xxx"}, dao, &fakeTransport{})
+
+	now := time.Date(2017, 5, 4, 12, 0, 0, 0, time.Local)
+	assert.Equal(t,
+		"No cities are watched in this channel, you may use:\n><@bot> watch Helsinki",
+		b.watchingHandler("C1"))
+	// misspelled name is corrected
+	assert.Equal(t,
+		"The digest of events in _Helsinki_ will be posted in this channel every Monday.",
+		b.watchHandler("C1", Query{Command: "watch", City: "helsinky"}, now))
+	assert.Equal(t,
+		"The following cities are watched in this channel:\n>Helsinki (next digest at 8 May 2017 09:00)\n",
+		b.watchingHandler("C1"))
+
+	digest, err := b.digest("Helsinki", time.Date(2017, 5, 8, digestHour, 0, 0, 0, time.Local))
+	assert.NoError(t, err)
+	assert.Equal(t,
+		"Events in _Helsinki_ for the week 8 May - 14 May:\n>9 May 2017, *Metallica* (Helsinki) \n",
+		digest)
+
+	assert.Equal(t,
+		"The digest of events in _Helsinki_ won't be posted in this channel anymore.",
+		b.unwatchHandler("C1", Query{Command: "unwatch", City: "Helsinki"}))
+	watches, err := dao.GetWatches("C1")
+	assert.NoError(t, err)
+	assert.Empty(t, watches)
+}
+
+func TestICalHandler(t *testing.T) {
+	dao := memory.New(config.DBConfig{Type: "memory"})
+	defer dao.Close()
+	dao.AddBandEvents([]store.Event{
+		{Band: "AC/DC", Title: "AC/DC", From: 1493856000, To: 1493856000, City: "St Petersburg"},
+	})
+	b := New(config.BotConfig{Token: "xxx"}, dao, &fakeTransport{})
+	assert.Equal(t,
+		"Sorry, calendar feeds aren't available.",
+		b.icalHandler(Query{Command: "ical", Band: "AC/DC"}))
+
+	b = New(config.BotConfig{Token: "xxx", ICalURL: "https://rocker.example.com/"}, dao, &fakeTransport{})
+	// misspelled names are corrected
+	assert.Equal(t,
+		"Subscribe to the calendar of events of *AC/DC*:\nhttps://rocker.example.com/ical/band/AC%2FDC.ics",
+		b.icalHandler(Query{Command: "ical", Band: "ac/dc"}))
+	assert.Equal(t,
+		"Subscribe to the calendar of events in _St Petersburg_:\nhttps://rocker.example.com/ical/city/St%20Petersburg.ics",
+		b.icalHandler(Query{Command: "ical", City: "st petersburg"}))
+	// city may have no events yet
+	assert.Equal(t,
+		"Subscribe to the calendar of events in _Helsinki_:\nhttps://rocker.example.com/ical/city/Helsinki.ics",
+		b.Reply(Message{Channel: "C1", User: "U1", Text: "ical in Helsinki"}).Text)
+}
+
+// fakeTransport receives messages from channel, fails the first sends
+// and keeps the sent messages. Its markup is like Slack's one.
+type fakeTransport struct {
+	messages   chan Message
+	connectErr error
+	mu         sync.Mutex
+	sendErrs   int
+	sends      int
+	sent       []Message
+	closed     bool
+}
+
+func (t *fakeTransport) Connect() (string, error) { return "bot", t.connectErr }
+
+func (t *fakeTransport) Receive() (Message, error) {
+	if m, ok := <-t.messages; ok {
+		return m, nil
+	}
+	return Message{}, errors.New("not implemented")
+}
+
+func (t *fakeTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closed = true
+	return nil
+}
+
+func (t *fakeTransport) Send(msg Message) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sends++
+	if t.sends <= t.sendErrs {
+		return errors.New("send failed")
+	}
+	t.sent = append(t.sent, msg)
+	return nil
+}
+
+func (t *fakeTransport) Bold(text string) string    { return "*" + text + "*" }
+func (t *fakeTransport) Italic(text string) string  { return "_" + text + "_" }
+func (t *fakeTransport) Quote(line string) string   { return ">" + line }
+func (t *fakeTransport) Mention(user string) string { return "<@" + user + ">" }
+func (t *fakeTransport) Command(text string) string { return "<@bot> " + text }
+
+func TestSendReply(t *testing.T) {
+	dao := memory.New(config.DBConfig{Type: "memory"})
+	defer dao.Close()
+	reply := Message{Channel: "C1", Text: "reply"}
+
+	// replies are not sent until the bot is connected
+	ft := &fakeTransport{}
+	b := New(config.BotConfig{Token: "xxx", ReplyPolicy: "drop"}, dao, ft)
+	assert.Equal(t, errNotConnected, b.send(reply))
+	assert.NoError(t, b.connect())
+	assert.NoError(t, b.send(reply))
+
+	// failed reply is dropped
+	ft = &fakeTransport{sendErrs: 1}
+	b.t = ft
+	b.sendReply(context.Background(), reply)
+	assert.Equal(t, 1, ft.sends)
+	assert.Empty(t, ft.sent)
+
+	// failed reply is resent
+	ft = &fakeTransport{sendErrs: 1}
+	b = New(config.BotConfig{Token: "xxx"}, dao, ft)
+	assert.NoError(t, b.connect())
+	b.sendReply(context.Background(), reply)
+	assert.Equal(t, 2, ft.sends)
+	assert.Equal(t, []Message{reply}, ft.sent)
+
+	// failed reply isn't resent after ctx is done
+	ft = &fakeTransport{sendErrs: attemptsToSendReply}
+	b.t = ft
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	b.sendReply(ctx, reply)
+	assert.Equal(t, 1, ft.sends)
+	assert.Empty(t, ft.sent)
+}
+
+func TestStartStop(t *testing.T) {
+	dao := memory.New(config.DBConfig{Type: "memory"})
+	defer dao.Close()
+
+	ft := &fakeTransport{messages: make(chan Message, 1)}
+	b := New(config.BotConfig{Token: "xxx", NumHandlers: 1, NumSenders: 1}, dao, ft)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		b.Start(ctx)
+		close(stopped)
+	}()
+
+	ft.messages <- Message{Channel: "C1", Text: "help"}
+	for i := 0; i < 100; i++ {
+		ft.mu.Lock()
+		n := len(ft.sent)
+		ft.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// receive is blocked, but the bot is stopped
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("bot is not stopped")
+	}
+	assert.True(t, ft.closed)
+	assert.Len(t, ft.sent, 1)
+
+	// failed connect is retried until the bot is stopped
+	ft = &fakeTransport{connectErr: errors.New("connect failed")}
+	b = New(config.BotConfig{Token: "xxx"}, dao, ft)
+	ctx, cancel = context.WithCancel(context.Background())
+	stopped = make(chan struct{})
+	go func() {
+		b.Start(ctx)
+		close(stopped)
+	}()
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("bot is not stopped")
+	}
+	assert.Empty(t, ft.sent)
+}
+
+func TestReply(t *testing.T) {
+	dao := memory.New(config.DBConfig{Type: "memory"})
+	defer dao.Close()
+	dao.AddBandEvents([]store.Event{
+		{Band: "Metallica", Title: "Metallica", From: 1493856000, To: 1493856000, City: "Paris"},
+		{Band: "Metallica", Title: "Metallica", From: 1493942400, To: 1493942400, City: "Paris"},
+	})
+	b := New(config.BotConfig{Token: "xxx", LongReplyLines: 3}, dao, &fakeTransport{})
+
+	// command in channel
+	reply := b.Reply(Message{Channel: "C1", User: "U1", Text: "following", Id: "1.1"})
+	assert.Equal(t, "C1", reply.Channel)
+	assert.Empty(t, reply.User)
+	assert.Empty(t, reply.ThreadId)
+	assert.Nil(t, reply.Page)
+
+	// reply with events has page of them
+	reply = b.Reply(Message{Channel: "D1", User: "U1", Text: "events of Metallica", Direct: true})
+	assert.Equal(t, "D1", reply.Channel)
+	assert.Contains(t, reply.Text, "events of *Metallica*")
+	assert.Len(t, reply.Page.Events, 2)
+	assert.Equal(t, "We known about the following events of *Metallica*:\n", reply.Page.Header)
+	assert.False(t, reply.Page.More())
+	// long replies to direct messages aren't moved into threads
+	assert.Empty(t, reply.ThreadId)
+
+	// reply to command in thread is posted in the thread
+	reply = b.Reply(Message{Channel: "C1", User: "U1", Text: "following", Id: "1.2", ThreadId: "1.0"})
+	assert.Equal(t, "1.0", reply.ThreadId)
+
+	// long reply is moved into thread of the command
+	reply = b.Reply(Message{Channel: "C1", User: "U1", Text: "events of Metallica", Id: "1.3"})
+	assert.Equal(t, "1.3", reply.ThreadId)
+}
+
+// markdownTransport is a fake transport with markup like Mattermost's one
+// and small pages of events.
+type markdownTransport struct {
+	fakeTransport
+}
+
+func (t *markdownTransport) Bold(text string) string    { return "**" + text + "**" }
+func (t *markdownTransport) Italic(text string) string  { return "*" + text + "*" }
+func (t *markdownTransport) Quote(line string) string   { return "> " + line }
+func (t *markdownTransport) Mention(user string) string { return "@" + user }
+func (t *markdownTransport) Command(text string) string { return "@rocker " + text }
+func (t *markdownTransport) PageSize() int              { return 1 }
+
+func (t *markdownTransport) DirectCommand(text string) string { return "`" + text + "`" }
+
+func TestTransportMarkup(t *testing.T) {
+	dao := memory.New(config.DBConfig{Type: "memory"})
+	defer dao.Close()
+	dao.AddBandEvents([]store.Event{
+		{Band: "Metallica", Title: "Metallica", From: 1493856000, To: 1493856000, City: "Paris", Venue: "Stade de France"},
+		{Band: "Metallica", Title: "Metallica", From: 1493942400, To: 1493942400, City: "Paris"},
+	})
+	dao.Follow(store.Subscription{User: "john", Channel: "C1", Band: "Metallica"})
+	ft := &markdownTransport{}
+	b := New(config.BotConfig{}, dao, ft)
+
+	reply := b.Reply(Message{Channel: "C1", Text: "events of Metallica"})
+	assert.Equal(t,
+		"We known about the following events of **Metallica**:\n> 4 May 2017, **Metallica** (Paris - *Stade de France*) \n"+
+			"To load next portion of events you may use:\n> @rocker events of Metallica since 04 May 2017",
+		reply.Text)
+	assert.Equal(t, 1, reply.Page.Limit)
+	assert.True(t, reply.Page.More())
+	assert.Equal(t, "To load next portion of events you may use:\n> @rocker events of Metallica since 04 May 2017", reply.Page.Footer)
+
+	help := b.Reply(Message{Channel: "C1", Text: "help"}).Text
+	assert.Contains(t, help, "> @rocker following - list bands you follow in the channel\n")
+	assert.Contains(t, help, "without mention of the bot, e.g. `events of Metallica`.\n")
+	// commands in direct messages are mentioned only if the transport receives them
+	help = New(config.BotConfig{}, dao, &fakeTransport{}).Reply(Message{Channel: "C1", Text: "help"}).Text
+	assert.NotContains(t, help, "In direct messages")
+
+	messages := b.newEventsMessages([]store.Event{
+		{Band: "Metallica", Title: "Metallica", From: 1493856000, To: 1493856000, City: "Paris"},
+	}, time.Unix(1493856000, 0))
+	assert.Equal(t, []Message{
+		{Channel: "C1", Text: "@john New events of **Metallica**:\n> 4 May 2017, **Metallica** (Paris) \n"},
+	}, messages)
+}
+
+func TestREPL(t *testing.T) {
+	dao := memory.New(config.DBConfig{Type: "memory"})
+	defer dao.Close()
+	dao.AddBandEvents([]store.Event{
+		{Band: "Metallica", Title: "Metallica", From: 1493856000, To: 1493856000, City: "Paris"},
+	})
+
+	in := strings.NewReader("events of Metallica\n\n:query\n@rocker events in Paris\n:quit\nhelp\n")
+	var out bytes.Buffer
+	assert.NoError(t, REPL(config.BotConfig{}, dao, in, &out, false))
+	assert.Equal(t, replHelp+
+		"> We known about the following events of *Metallica*:\n  4 May 2017, *Metallica* (Paris) \n"+
+		"> > Printing of queries is on.\n"+
+		"> {\n  \"Command\": \"events\",\n  \"Band\": \"\",\n  \"City\": \"Paris\",\n  \"Country\": \"\",\n"+
+		"  \"Venue\": \"\",\n  \"Genre\": \"\",\n  \"From\": 0,\n  \"To\": 0\n}\n"+
+		"We known about the following events in _Paris_:\n  4 May 2017, *Metallica* (Paris) \n"+
+		"> ",
+		out.String())
+
+	// it returns at the end of input
+	out.Reset()
+	assert.NoError(t, REPL(config.BotConfig{}, dao, strings.NewReader("following"), &out, true))
+	assert.Contains(t, out.String(), "\"Command\": \"following\"")
+	assert.Contains(t, out.String(), "You don't follow any band in this channel, you may use:\n  follow Metallica\n")
+
+	// commands which change the db aren't run
+	out.Reset()
+	assert.NoError(t, REPL(config.BotConfig{}, dao, strings.NewReader("follow Metallica\nwatch Paris\n"), &out, false))
+	assert.Equal(t, replHelp+
+		"> Command follow changes the db, it isn't available in REPL.\n"+
+		"> Command watch changes the db, it isn't available in REPL.\n"+
+		"> \n",
+		out.String())
+	subscriptions, err := dao.GetSubscriptions("repl", "repl")
+	assert.NoError(t, err)
+	assert.Empty(t, subscriptions)
+	watches, err := dao.GetWatches("repl")
+	assert.NoError(t, err)
+	assert.Empty(t, watches)
+}
@@ -0,0 +1,405 @@
+// Package discord connects the bot to Discord by gateway websocket and REST API.
+package discord
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/austinov/rocker-bot/bot"
+	"github.com/austinov/rocker-bot/config"
+
+	"golang.org/x/net/websocket"
+)
+
+const (
+	defaultGatewayURL = "wss://gateway.discord.gg/?v=10&encoding=json"
+	defaultAPIURL     = "https://discord.com/api/v10/"
+	// intents of guilds, guild messages and direct messages
+	intents = 1<<0 | 1<<9 | 1<<12
+	// pageSize is a number of events in reply,
+	// Discord accepts up to 10 embeds in message
+	pageSize = 10
+	// commandName is a name of slash command registered in guilds
+	commandName = "rocker"
+	// types of interactions and responses to them
+	applicationCommand = 2
+	deferredResponse   = 5
+)
+
+// Transport receives mentions of the bot, direct messages and slash commands
+// through gateway websocket and sends replies by REST API.
+type Transport struct {
+	cfg     config.DiscordConfig
+	api     *restAPI
+	mu      sync.Mutex // it guards ws, stop and guilds
+	ws      *websocket.Conn
+	stop    chan struct{}     // it stops heartbeats of the connection
+	guilds  map[string]string // guild ids by ids of their channels
+	wmu     sync.Mutex        // it serializes writes into websocket
+	seq     int64             // sequence number of the last event, it's changed atomically
+	id      string            // mention of the bot, it's set on the first connect
+	userId  string
+	appId   string
+	replier bot.Replier
+}
+
+func New(cfg config.DiscordConfig) *Transport {
+	if cfg.GatewayURL == "" {
+		cfg.GatewayURL = defaultGatewayURL
+	}
+	if cfg.APIURL == "" {
+		cfg.APIURL = defaultAPIURL
+	}
+	return &Transport{
+		cfg:    cfg,
+		api:    newRestAPI(cfg.Token, cfg.APIURL),
+		guilds: make(map[string]string),
+	}
+}
+
+// SetReplier sets the bot which replies to slash commands.
+func (t *Transport) SetReplier(r bot.Replier) {
+	t.replier = r
+}
+
+// Connect opens gateway connection, identifies the bot and starts heartbeats.
+// Slash command is registered in the configured guilds on the first connect.
+func (t *Transport) Connect() (string, error) {
+	ws, err := websocket.Dial(t.cfg.GatewayURL, "", t.cfg.APIURL)
+	if err != nil {
+		return "", err
+	}
+	interval, ready, err := t.identify(ws)
+	if err != nil {
+		ws.Close()
+		return "", err
+	}
+
+	t.mu.Lock()
+	t.closeLocked()
+	t.ws = ws
+	t.stop = make(chan struct{})
+	go t.heartbeat(ws, interval, t.stop)
+	t.mu.Unlock()
+
+	if t.id == "" {
+		t.id = "<@" + ready.User.Id + ">"
+		t.userId = ready.User.Id
+		t.appId = ready.Application.Id
+		t.registerCommands()
+	}
+	return ready.User.Id, nil
+}
+
+// identify receives hello of the gateway, identifies the bot
+// and waits for ready event.
+func (t *Transport) identify(ws *websocket.Conn) (time.Duration, Ready, error) {
+	var ready Ready
+	var p Payload
+	if err := websocket.JSON.Receive(ws, &p); err != nil {
+		return 0, ready, err
+	}
+	var hello Hello
+	if p.Op != opHello || json.Unmarshal(p.D, &hello) != nil || hello.HeartbeatInterval <= 0 {
+		return 0, ready, fmt.Errorf("Unexpected gateway payload %d instead of hello", p.Op)
+	}
+	d, _ := json.Marshal(Identify{
+		Token:      t.cfg.Token,
+		Intents:    intents,
+		Properties: IdentifyProperties{Os: "linux", Browser: "rocker-bot", Device: "rocker-bot"},
+	})
+	if err := t.write(ws, Payload{Op: opIdentify, D: d}); err != nil {
+		return 0, ready, err
+	}
+	for {
+		if err := websocket.JSON.Receive(ws, &p); err != nil {
+			return 0, ready, err
+		}
+		switch {
+		case p.Op == opInvalidSession:
+			return 0, ready, errors.New("Discord rejected the session")
+		case p.Op == opDispatch && p.T == "READY":
+			atomic.StoreInt64(&t.seq, p.S)
+			if err := json.Unmarshal(p.D, &ready); err != nil {
+				return 0, ready, err
+			}
+			return time.Duration(hello.HeartbeatInterval) * time.Millisecond, ready, nil
+		}
+	}
+}
+
+// heartbeat sends heartbeats with the interval until stop is closed,
+// the connection is closed if heartbeat fails.
+func (t *Transport) heartbeat(ws *websocket.Conn, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := t.write(ws, t.heartbeatPayload()); err != nil {
+				ws.Close()
+				return
+			}
+		}
+	}
+}
+
+func (t *Transport) heartbeatPayload() Payload {
+	return Payload{Op: opHeartbeat, D: json.RawMessage(strconv.FormatInt(atomic.LoadInt64(&t.seq), 10))}
+}
+
+func (t *Transport) write(ws *websocket.Conn, p Payload) error {
+	t.wmu.Lock()
+	defer t.wmu.Unlock()
+	return websocket.JSON.Send(ws, p)
+}
+
+func (t *Transport) conn() (*websocket.Conn, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.ws == nil {
+		return nil, errors.New("Discord gateway connection is closed")
+	}
+	return t.ws, nil
+}
+
+// registerCommands registers slash command in the guilds where it's enabled.
+func (t *Transport) registerCommands() {
+	command := ApplicationCommand{
+		Name:        commandName,
+		Description: "Calendar of rock concerts",
+		Options: []CommandOption{{
+			Type:        3, // string
+			Name:        "command",
+			Description: "Command to the bot, e.g. events of Metallica",
+		}},
+	}
+	for id, g := range t.cfg.Guilds {
+		if !g.Slash {
+			continue
+		}
+		path := "applications/" + t.appId + "/guilds/" + id + "/commands"
+		if err := t.api.call("PUT", path, []ApplicationCommand{command}, nil); err != nil {
+			fmt.Fprintf(os.Stderr, "registration of slash command in guild %s failed with %#v\n", id, err)
+		}
+	}
+}
+
+// Receive returns the next command to the bot. Other events are skipped,
+// slash commands are replied in separate go-routines.
+// It must not be called from several go-routines.
+func (t *Transport) Receive() (bot.Message, error) {
+	for {
+		ws, err := t.conn()
+		if err != nil {
+			return bot.Message{}, err
+		}
+		var p Payload
+		if err := websocket.JSON.Receive(ws, &p); err != nil {
+			return bot.Message{}, err
+		}
+		switch p.Op {
+		case opHeartbeat:
+			if err := t.write(ws, t.heartbeatPayload()); err != nil {
+				return bot.Message{}, err
+			}
+		case opReconnect, opInvalidSession:
+			return bot.Message{}, errors.New("Discord asks to reconnect")
+		case opDispatch:
+			if p.S > 0 {
+				atomic.StoreInt64(&t.seq, p.S)
+			}
+			if m, ok := t.dispatch(p); ok {
+				return m, nil
+			}
+		}
+	}
+}
+
+// dispatch handles event of the gateway, it returns message
+// if the event is command to the bot.
+func (t *Transport) dispatch(p Payload) (bot.Message, bool) {
+	switch p.T {
+	case "GUILD_CREATE":
+		var g Guild
+		if err := json.Unmarshal(p.D, &g); err == nil {
+			for _, c := range g.Channels {
+				t.setGuild(c.Id, g.Id)
+			}
+		}
+	case "MESSAGE_CREATE":
+		var m Message
+		if err := json.Unmarshal(p.D, &m); err != nil {
+			fmt.Fprintf(os.Stderr, "illegal message: %v\n", err)
+			break
+		}
+		if m.GuildId != "" {
+			t.setGuild(m.ChannelId, m.GuildId)
+		}
+		if text, ok := t.command(m); ok {
+			return bot.Message{
+				Channel: m.ChannelId,
+				User:    m.Author.Id,
+				Text:    text,
+				Id:      m.Id,
+				Direct:  m.GuildId == "",
+			}, true
+		}
+	case "INTERACTION_CREATE":
+		var i Interaction
+		if err := json.Unmarshal(p.D, &i); err != nil {
+			fmt.Fprintf(os.Stderr, "illegal interaction: %v\n", err)
+			break
+		}
+		go t.interact(i)
+	}
+	return bot.Message{}, false
+}
+
+// command returns text of command without mention of the bot if the message
+// is addressed to the bot. Commands in direct messages may be without mention.
+// Mentions in guild are answered in the configured channels only.
+func (t *Transport) command(m Message) (string, bool) {
+	if m.Author.Bot || m.Author.Id == t.userId {
+		return "", false
+	}
+	text := strings.TrimSpace(m.Content)
+	if m.GuildId == "" {
+		text = strings.TrimPrefix(text, "<@"+t.userId+">")
+		text = strings.TrimPrefix(text, "<@!"+t.userId+">")
+		return strings.TrimSpace(text), true
+	}
+	if !t.allowed(m.GuildId, m.ChannelId) {
+		return "", false
+	}
+	for _, mention := range []string{"<@" + t.userId + ">", "<@!" + t.userId + ">"} {
+		if strings.HasPrefix(text, mention) {
+			return strings.TrimSpace(strings.TrimPrefix(text, mention)), true
+		}
+	}
+	return "", false
+}
+
+// allowed returns true if the bot answers in the channel of the guild.
+func (t *Transport) allowed(guild, channel string) bool {
+	channels := t.cfg.Guilds[guild].Channels
+	if len(channels) == 0 {
+		return true
+	}
+	for _, c := range channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Transport) setGuild(channel, guild string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.guilds[channel] = guild
+}
+
+// guild returns id of guild of the channel, it's empty for direct
+// messages and unknown channels.
+func (t *Transport) guild(channel string) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.guilds[channel]
+}
+
+// interact replies to slash command. The command is acknowledged at once and
+// the response is edited later, since Discord waits for the response 3 seconds only.
+func (t *Transport) interact(i Interaction) {
+	if i.Type != applicationCommand || i.Data.Name != commandName {
+		return
+	}
+	err := t.api.call("POST", "interactions/"+i.Id+"/"+i.Token+"/callback", InteractionResponse{Type: deferredResponse}, nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "acknowledgement of slash command failed with %#v\n", err)
+		return
+	}
+	msg := bot.Message{Channel: i.ChannelId, Direct: i.GuildId == ""}
+	if i.Member != nil {
+		msg.User = i.Member.User.Id
+	} else if i.User != nil {
+		msg.User = i.User.Id
+	}
+	for _, o := range i.Data.Options {
+		if o.Name == "command" {
+			msg.Text = strings.TrimSpace(o.Value)
+		}
+	}
+	resp := t.render(t.replier.Reply(msg), i.GuildId)
+	err = t.api.call("PATCH", "webhooks/"+t.appId+"/"+i.Token+"/messages/@original", resp, nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "response to slash command failed with %#v\n", err)
+	}
+}
+
+// Send sends the reply, reply in thread is sent as reply to the command.
+func (t *Transport) Send(msg bot.Message) error {
+	m := t.render(msg, t.guild(msg.Channel))
+	if msg.ThreadId != "" {
+		m.MessageReference = &MessageReference{MessageId: msg.ThreadId}
+	}
+	return t.api.call("POST", "channels/"+msg.Channel+"/messages", m, nil)
+}
+
+// Close closes gateway connection, blocked Receive returns error.
+func (t *Transport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.closeLocked()
+}
+
+func (t *Transport) closeLocked() error {
+	if t.stop != nil {
+		close(t.stop)
+		t.stop = nil
+	}
+	if t.ws == nil {
+		return nil
+	}
+	err := t.ws.Close()
+	t.ws = nil
+	return err
+}
+
+// PageSize returns a maximum number of events in reply.
+func (t *Transport) PageSize() int {
+	return pageSize
+}
+
+func (t *Transport) Bold(text string) string {
+	return "**" + text + "**"
+}
+
+func (t *Transport) Italic(text string) string {
+	return "*" + text + "*"
+}
+
+func (t *Transport) Quote(line string) string {
+	return "> " + line
+}
+
+func (t *Transport) Mention(user string) string {
+	return "<@" + user + ">"
+}
+
+func (t *Transport) Command(text string) string {
+	return t.id + " " + text
+}
+
+func (t *Transport) DirectCommand(text string) string {
+	return "`" + text + "`"
+}
@@ -0,0 +1,213 @@
+package discord
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/austinov/rocker-bot/bot"
+	"github.com/austinov/rocker-bot/config"
+	"github.com/austinov/rocker-bot/store"
+	"github.com/austinov/rocker-bot/store/memory"
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/websocket"
+)
+
+// request is a request to REST API received by stub.
+type request struct {
+	method, path, body string
+}
+
+// stubDiscord is a stub of Discord's gateway and REST API. The gateway sends
+// the events after ready and then receives heartbeats.
+type stubDiscord struct {
+	events     []Payload
+	identify   chan Identify
+	heartbeats chan int64
+	requests   chan request
+}
+
+func newStubDiscord(events ...Payload) *stubDiscord {
+	return &stubDiscord{
+		events:     events,
+		identify:   make(chan Identify, 1),
+		heartbeats: make(chan int64, 100),
+		requests:   make(chan request, 10),
+	}
+}
+
+func dispatch(t string, s int64, d interface{}) Payload {
+	data, _ := json.Marshal(d)
+	return Payload{Op: opDispatch, T: t, S: s, D: data}
+}
+
+func (s *stubDiscord) gateway(ws *websocket.Conn) {
+	hello, _ := json.Marshal(Hello{HeartbeatInterval: 10})
+	websocket.JSON.Send(ws, Payload{Op: opHello, D: hello})
+	var p Payload
+	if err := websocket.JSON.Receive(ws, &p); err != nil || p.Op != opIdentify {
+		return
+	}
+	var identify Identify
+	json.Unmarshal(p.D, &identify)
+	s.identify <- identify
+	websocket.JSON.Send(ws, dispatch("READY", 1, Ready{User: User{Id: "B1", Bot: true}, Application: Application{Id: "A1"}}))
+	for _, e := range s.events {
+		websocket.JSON.Send(ws, e)
+	}
+	for {
+		if err := websocket.JSON.Receive(ws, &p); err != nil {
+			return
+		}
+		if p.Op == opHeartbeat {
+			var seq int64
+			json.Unmarshal(p.D, &seq)
+			s.heartbeats <- seq
+		}
+	}
+}
+
+func (s *stubDiscord) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/gateway" {
+		websocket.Handler(s.gateway).ServeHTTP(w, r)
+		return
+	}
+	if r.Header.Get("Authorization") != "Bot xxx" {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"code": 0, "message": "401: Unauthorized"}`))
+		return
+	}
+	body, _ := ioutil.ReadAll(r.Body)
+	s.requests <- request{r.Method, strings.TrimPrefix(r.URL.Path, "/api/"), string(body)}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func TestTransport(t *testing.T) {
+	dao := memory.New(config.DBConfig{Type: "memory"})
+	defer dao.Close()
+	message := func(id, guild, channel, author, content string) Payload {
+		return dispatch("MESSAGE_CREATE", 3, Message{Id: id, GuildId: guild, ChannelId: channel, Author: User{Id: author}, Content: content})
+	}
+	stub := newStubDiscord(
+		dispatch("GUILD_CREATE", 2, Guild{Id: "G2", Channels: []Channel{{Id: "C3"}}}),
+		// messages without mention, mentions in other channels
+		// and messages of bots are skipped
+		message("M1", "G1", "C1", "U1", "events of Metallica"),
+		message("M2", "G1", "C2", "U1", "<@B1> events of Metallica"),
+		dispatch("MESSAGE_CREATE", 3, Message{Id: "M3", GuildId: "G1", ChannelId: "C1", Author: User{Id: "U2", Bot: true}, Content: "<@B1> help"}),
+		message("M4", "G1", "C1", "U1", "<@!B1> events of Metallica"),
+		message("M5", "", "D1", "U1", "following"),
+		dispatch("INTERACTION_CREATE", 4, Interaction{Id: "I1", Type: applicationCommand, Token: "T1", GuildId: "G2", ChannelId: "C3",
+			Member: &Member{User: User{Id: "U1"}}, Data: InteractionData{Name: "rocker", Options: []InteractionOption{{Name: "command", Type: 3, Value: "help"}}}}),
+	)
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	tr := New(config.DiscordConfig{
+		Token:      "xxx",
+		GatewayURL: "ws" + strings.TrimPrefix(server.URL, "http") + "/gateway",
+		APIURL:     server.URL + "/api/",
+		Guilds: map[string]config.DiscordGuildConfig{
+			"G1": {Slash: true, Channels: []string{"C1"}},
+			"G2": {Format: "text"},
+		},
+	})
+	bot.New(config.BotConfig{}, dao, tr)
+
+	id, err := tr.Connect()
+	assert.NoError(t, err)
+	assert.Equal(t, "B1", id)
+	assert.Equal(t, "<@B1> help", tr.Command("help"))
+	identify := <-stub.identify
+	assert.Equal(t, "xxx", identify.Token)
+	assert.Equal(t, intents, identify.Intents)
+	// slash command is registered in G1 only
+	r := <-stub.requests
+	assert.Equal(t, "PUT", r.method)
+	assert.Equal(t, "applications/A1/guilds/G1/commands", r.path)
+	assert.Contains(t, r.body, `"name":"rocker"`)
+
+	m, err := tr.Receive()
+	assert.NoError(t, err)
+	assert.Equal(t, bot.Message{Channel: "C1", User: "U1", Text: "events of Metallica", Id: "M4"}, m)
+	m, err = tr.Receive()
+	assert.NoError(t, err)
+	assert.Equal(t, bot.Message{Channel: "D1", User: "U1", Text: "following", Id: "M5", Direct: true}, m)
+	assert.Equal(t, "G2", tr.guild("C3"))
+
+	// slash command is acknowledged and its response is edited
+	received := make(chan error, 1)
+	go func() {
+		_, err := tr.Receive()
+		received <- err
+	}()
+	r = <-stub.requests
+	assert.Equal(t, request{"POST", "interactions/I1/T1/callback", `{"type":5}`}, r)
+	r = <-stub.requests
+	assert.Equal(t, "PATCH", r.method)
+	assert.Equal(t, "webhooks/A1/T1/messages/@original", r.path)
+	assert.Contains(t, r.body, "Please, use commands")
+
+	// heartbeats have sequence number of the last event
+	select {
+	case seq := <-stub.heartbeats:
+		assert.True(t, seq >= 1)
+	case <-time.After(time.Second):
+		t.Fatal("no heartbeats")
+	}
+
+	// reply in thread is sent as reply to the command
+	assert.NoError(t, tr.Send(bot.Message{Channel: "C1", Text: "**Metallica**", ThreadId: "M4"}))
+	assert.Equal(t, request{"POST", "channels/C1/messages",
+		`{"content":"**Metallica**","message_reference":{"message_id":"M4"}}`}, <-stub.requests)
+
+	assert.NoError(t, tr.Close())
+	select {
+	case err := <-received:
+		assert.Error(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("receive is not stopped by close")
+	}
+}
+
+func TestRender(t *testing.T) {
+	dao := memory.New(config.DBConfig{Type: "memory"})
+	defer dao.Close()
+	dao.AddBandEvents([]store.Event{
+		{Band: "Metallica", Title: "Metallica", From: 1493856000, To: 1493856000, City: "Paris", Venue: "Stade de France",
+			Link: "http://example.com/event", Img: "http://example.com/poster.jpg"},
+	})
+	tr := New(config.DiscordConfig{
+		Token:  "xxx",
+		Guilds: map[string]config.DiscordGuildConfig{"G2": {Format: "text"}},
+	})
+	b := bot.New(config.BotConfig{}, dao, tr)
+	reply := b.Reply(bot.Message{Channel: "C1", Text: "events of Metallica"})
+	assert.Equal(t, pageSize, reply.Page.Limit)
+
+	// events are rendered as embeds by default
+	m := tr.render(reply, "G1")
+	assert.Equal(t, "We known about the following events of **Metallica**:\n", m.Content)
+	assert.Equal(t, []Embed{{
+		Title: "Metallica",
+		Url:   "http://example.com/event",
+		Fields: []EmbedField{
+			{Name: "Date", Value: "4 May 2017", Inline: true},
+			{Name: "City", Value: "Paris", Inline: true},
+			{Name: "Venue", Value: "Stade de France", Inline: true},
+		},
+		Thumbnail: &EmbedImage{Url: "http://example.com/poster.jpg"},
+	}}, m.Embeds)
+
+	// guild may use text
+	m = tr.render(reply, "G2")
+	assert.Equal(t,
+		"We known about the following events of **Metallica**:\n> 4 May 2017, **Metallica** (Paris - *Stade de France*) - http://example.com/event\n",
+		m.Content)
+	assert.Nil(t, m.Embeds)
+}
@@ -0,0 +1,57 @@
+package discord
+
+import (
+	"strings"
+
+	"github.com/austinov/rocker-bot/bot"
+	"github.com/austinov/rocker-bot/store"
+)
+
+// embedsFormat renders events as embeds with posters
+const embedsFormat = "embeds"
+
+// render returns message of the reply. Events are rendered as embeds unless
+// the guild is configured to use text, the text of events is replaced
+// by the embeds then.
+func (t *Transport) render(reply bot.Message, guild string) CreateMessage {
+	m := CreateMessage{Content: reply.Text}
+	p := reply.Page
+	if p != nil && len(p.Events) > 0 && t.format(guild) == embedsFormat {
+		m.Content = p.Header + p.Footer
+		m.Embeds = formatEmbeds(p.Events)
+	}
+	return m
+}
+
+// format returns format of events in the guild.
+func (t *Transport) format(guild string) string {
+	if f := t.cfg.Guilds[guild].Format; f != "" {
+		return f
+	}
+	return embedsFormat
+}
+
+// formatEmbeds returns embeds of the events: title with link to details,
+// date, city and venue and poster of the event.
+func formatEmbeds(events []store.Event) []Embed {
+	embeds := make([]Embed, 0, len(events))
+	for _, e := range events {
+		embed := Embed{
+			Title: e.Title,
+			Url:   e.Link,
+		}
+		embed.Fields = append(embed.Fields, EmbedField{Name: "Date", Value: bot.FormatDates(e), Inline: true})
+		if e.City != "" {
+			embed.Fields = append(embed.Fields, EmbedField{Name: "City", Value: e.City, Inline: true})
+		}
+		if e.Venue != "" {
+			embed.Fields = append(embed.Fields, EmbedField{Name: "Venue", Value: e.Venue, Inline: true})
+		}
+		// Discord loads images by absolute urls only
+		if strings.HasPrefix(e.Img, "http") {
+			embed.Thumbnail = &EmbedImage{Url: e.Img}
+		}
+		embeds = append(embeds, embed)
+	}
+	return embeds
+}
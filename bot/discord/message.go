@@ -0,0 +1,154 @@
+package discord
+
+import "encoding/json"
+
+// opcodes of gateway payloads
+const (
+	opDispatch       = 0
+	opHeartbeat      = 1
+	opIdentify       = 2
+	opReconnect      = 7
+	opInvalidSession = 9
+	opHello          = 10
+	opHeartbeatAck   = 11
+)
+
+// Payload is a message of gateway websocket.
+type Payload struct {
+	Op int             `json:"op"`
+	D  json.RawMessage `json:"d,omitempty"`
+	S  int64           `json:"s,omitempty"`
+	T  string          `json:"t,omitempty"`
+}
+
+type Hello struct {
+	HeartbeatInterval int64 `json:"heartbeat_interval"`
+}
+
+type Identify struct {
+	Token      string             `json:"token"`
+	Intents    int                `json:"intents"`
+	Properties IdentifyProperties `json:"properties"`
+}
+
+type IdentifyProperties struct {
+	Os      string `json:"os"`
+	Browser string `json:"browser"`
+	Device  string `json:"device"`
+}
+
+type Ready struct {
+	User        User        `json:"user"`
+	Application Application `json:"application"`
+}
+
+type Application struct {
+	Id string `json:"id"`
+}
+
+type User struct {
+	Id       string `json:"id"`
+	Username string `json:"username"`
+	Bot      bool   `json:"bot"`
+}
+
+// Guild is a payload of GUILD_CREATE event.
+type Guild struct {
+	Id       string    `json:"id"`
+	Channels []Channel `json:"channels"`
+}
+
+type Channel struct {
+	Id string `json:"id"`
+}
+
+// Message is a payload of MESSAGE_CREATE event.
+type Message struct {
+	Id        string `json:"id"`
+	ChannelId string `json:"channel_id"`
+	GuildId   string `json:"guild_id"`
+	Author    User   `json:"author"`
+	Content   string `json:"content"`
+}
+
+// Interaction is a payload of INTERACTION_CREATE event, e.g. slash command.
+type Interaction struct {
+	Id        string          `json:"id"`
+	Type      int             `json:"type"`
+	Token     string          `json:"token"`
+	GuildId   string          `json:"guild_id"`
+	ChannelId string          `json:"channel_id"`
+	Member    *Member         `json:"member"`
+	User      *User           `json:"user"`
+	Data      InteractionData `json:"data"`
+}
+
+type Member struct {
+	User User `json:"user"`
+}
+
+type InteractionData struct {
+	Name    string              `json:"name"`
+	Options []InteractionOption `json:"options"`
+}
+
+type InteractionOption struct {
+	Name  string `json:"name"`
+	Type  int    `json:"type"`
+	Value string `json:"value"`
+}
+
+// InteractionResponse is a request of interaction callback.
+type InteractionResponse struct {
+	Type int `json:"type"`
+}
+
+// ApplicationCommand is a slash command registered in guild.
+type ApplicationCommand struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Options     []CommandOption `json:"options,omitempty"`
+}
+
+type CommandOption struct {
+	Type        int    `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Required    bool   `json:"required"`
+}
+
+// CreateMessage is a request to create message in channel
+// or to edit response to interaction.
+type CreateMessage struct {
+	Content          string            `json:"content"`
+	Embeds           []Embed           `json:"embeds,omitempty"`
+	MessageReference *MessageReference `json:"message_reference,omitempty"`
+}
+
+type MessageReference struct {
+	MessageId string `json:"message_id"`
+}
+
+type Embed struct {
+	Title       string       `json:"title"`
+	Url         string       `json:"url,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Fields      []EmbedField `json:"fields,omitempty"`
+	Thumbnail   *EmbedImage  `json:"thumbnail,omitempty"`
+}
+
+type EmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+type EmbedImage struct {
+	Url string `json:"url"`
+}
+
+// ResponseError is an error response of REST API.
+type ResponseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
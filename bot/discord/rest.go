@@ -0,0 +1,64 @@
+package discord
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// restAPI calls Discord's REST API with the bot token.
+type restAPI struct {
+	token  string
+	apiURL string
+	client *http.Client
+}
+
+func newRestAPI(token, apiURL string) *restAPI {
+	return &restAPI{
+		token:  token,
+		apiURL: apiURL,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// call sends the request with JSON arguments to the path of REST API
+// and unmarshals the response into resp if it's not nil.
+func (a *restAPI) call(method, path string, args interface{}, resp interface{}) error {
+	var body []byte
+	if args != nil {
+		var err error
+		if body, err = json.Marshal(args); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, a.apiURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bot "+a.token)
+	r, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	if r.StatusCode >= 300 {
+		var respErr ResponseError
+		if json.Unmarshal(data, &respErr) == nil && respErr.Message != "" {
+			return fmt.Errorf("Discord error: %s", respErr.Message)
+		}
+		return fmt.Errorf("%s %s request failed with code %d", method, path, r.StatusCode)
+	}
+	if resp != nil && len(data) > 0 {
+		return json.Unmarshal(data, resp)
+	}
+	return nil
+}
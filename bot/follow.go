@@ -0,0 +1,129 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/austinov/rocker-bot/common"
+	"github.com/austinov/rocker-bot/store"
+)
+
+// followHandler subscribes user in channel to new events of the band.
+// The band may be misspelled, so the closest known name is used
+// or names are suggested.
+func (b *Bot) followHandler(user, channel string, query Query) string {
+	matches, err := b.dao.FindBands(query.Band, maxSuggestions)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return "Sorry, we have some troubles"
+	}
+	name, names := closestName(matches)
+	if name == "" && len(names) > 0 {
+		out := fmt.Sprintf("We don't know band %s. Did you mean:\n", b.t.Bold(query.Band))
+		for _, n := range names {
+			out += b.t.Quote(b.t.Command("follow "+n)) + "\n"
+		}
+		return out
+	}
+	if name == "" {
+		// band may have no events yet
+		name = query.Band
+	}
+	err = b.dao.Follow(store.Subscription{
+		User:    user,
+		Channel: channel,
+		Band:    name,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return "Sorry, we have some troubles"
+	}
+	return fmt.Sprintf("You follow %s now, new events will be posted in this channel.", b.t.Bold(name))
+}
+
+// unfollowHandler removes subscription of user in channel to the band.
+func (b *Bot) unfollowHandler(user, channel string, query Query) string {
+	err := b.dao.Unfollow(store.Subscription{
+		User:    user,
+		Channel: channel,
+		Band:    query.Band,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return "Sorry, we have some troubles"
+	}
+	return fmt.Sprintf("You don't follow %s anymore.", b.t.Bold(query.Band))
+}
+
+// followingHandler returns a reply containing bands which user follows in channel.
+func (b *Bot) followingHandler(user, channel string) string {
+	subscriptions, err := b.dao.GetSubscriptions(user, channel)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return "Sorry, we have some troubles"
+	}
+	if len(subscriptions) == 0 {
+		return "You don't follow any band in this channel, you may use:\n" + b.t.Quote(b.t.Command("follow Metallica"))
+	}
+	buffer := bytes.NewBufferString("You follow the following bands in this channel:\n")
+	for _, s := range subscriptions {
+		buffer.WriteString(b.t.Quote(s.Band) + "\n")
+	}
+	return buffer.String()
+}
+
+// NotifyNewEvents posts new upcoming events of bands to the channels
+// where the bands are followed. It's used as loader's listener.
+// The notifications are sent as replies, so they are resent
+// by the reply policy, e.g. while the bot is reconnecting.
+func (b *Bot) NotifyNewEvents(ctx context.Context, events []store.Event) {
+	for _, msg := range b.newEventsMessages(events, time.Now()) {
+		b.sendReply(ctx, msg)
+	}
+}
+
+// newEventsMessages returns messages about events which are not finished
+// at now, one message per band and channel mentioning band's followers.
+func (b *Bot) newEventsMessages(events []store.Event, now time.Time) []Message {
+	today := common.BeginOfDate(now).Unix()
+	bands := make([]string, 0)
+	bandEvents := make(map[string][]store.Event)
+	for _, e := range events {
+		if e.To < today {
+			continue
+		}
+		if _, ok := bandEvents[e.Band]; !ok {
+			bands = append(bands, e.Band)
+		}
+		bandEvents[e.Band] = append(bandEvents[e.Band], e)
+	}
+
+	messages := make([]Message, 0)
+	for _, band := range bands {
+		followers, err := b.dao.GetFollowers(band)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			continue
+		}
+		// followers are ordered by channel
+		for i := 0; i < len(followers); {
+			channel := followers[i].Channel
+			var mentions string
+			for ; i < len(followers) && followers[i].Channel == channel; i++ {
+				mentions += b.t.Mention(followers[i].User) + " "
+			}
+			text := fmt.Sprintf("%sNew events of %s:\n", mentions, b.t.Bold(followers[0].Band))
+			for _, e := range bandEvents[band] {
+				text += b.formatEvent(e)
+			}
+			messages = append(messages, Message{
+				Channel: channel,
+				Text:    text,
+			})
+		}
+	}
+	return messages
+}
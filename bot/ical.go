@@ -0,0 +1,48 @@
+package bot
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// icalHandler returns a reply containing link to iCal feed of events
+// of the band or in the city. The name may be misspelled, so the closest
+// known name is used or names are suggested.
+func (b *Bot) icalHandler(query Query) string {
+	if b.cfg.ICalURL == "" {
+		return "Sorry, calendar feeds aren't available."
+	}
+	kind, prep, name := "band", "of", query.Band
+	find, format := b.dao.FindBands, b.t.Bold
+	if query.City != "" {
+		kind, prep, name = "city", "in", query.City
+		find, format = b.dao.FindCities, b.t.Italic
+	}
+	matches, err := find(name, maxSuggestions)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return "Sorry, we have some troubles"
+	}
+	closest, names := closestName(matches)
+	if closest == "" && len(names) > 0 {
+		out := fmt.Sprintf("We don't know %s %s. Did you mean:\n", kind, format(name))
+		for _, n := range names {
+			out += b.t.Quote(b.t.Command("ical "+prep+" "+n)) + "\n"
+		}
+		return out
+	}
+	if closest != "" {
+		name = closest
+	}
+	return fmt.Sprintf("Subscribe to the calendar of events %s %s:\n%s",
+		prep, format(name), icalLink(b.cfg.ICalURL, kind, name))
+}
+
+// icalLink returns link to iCal feed of the band or city, slashes
+// in the name (e.g. AC/DC) are escaped too.
+func icalLink(baseURL, kind, name string) string {
+	return strings.TrimSuffix(baseURL, "/") + "/ical/" + kind + "/" +
+		strings.Replace(url.QueryEscape(name), "+", "%20", -1) + ".ics"
+}
@@ -0,0 +1,221 @@
+// Package mattermost connects the bot to Mattermost by websocket and REST API v4.
+package mattermost
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/austinov/rocker-bot/bot"
+	"github.com/austinov/rocker-bot/config"
+
+	"golang.org/x/net/websocket"
+)
+
+// Transport receives mentions of the bot and direct messages through
+// websocket of Mattermost and sends replies by REST API.
+// Users are identified by their usernames, so they are mentioned by them.
+type Transport struct {
+	cfg      config.MattermostConfig
+	apiURL   string
+	client   *http.Client
+	mu       sync.Mutex // it guards ws
+	ws       *websocket.Conn
+	userId   string
+	username string // username of the bot, it's set on the first connect
+}
+
+func New(cfg config.MattermostConfig) *Transport {
+	return &Transport{
+		cfg:    cfg,
+		apiURL: strings.TrimSuffix(cfg.URL, "/") + "/api/v4/",
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Connect checks the token, opens websocket connection and authenticates it.
+func (t *Transport) Connect() (string, error) {
+	var me User
+	if err := t.call("GET", "users/me", nil, &me); err != nil {
+		return "", err
+	}
+	wsURL := "ws" + strings.TrimPrefix(t.apiURL, "http") + "websocket"
+	ws, err := websocket.Dial(wsURL, "", t.cfg.URL)
+	if err != nil {
+		return "", err
+	}
+	auth := Action{Seq: 1, Action: "authentication_challenge", Data: AuthenticationData{Token: t.cfg.Token}}
+	if err := websocket.JSON.Send(ws, auth); err != nil {
+		ws.Close()
+		return "", err
+	}
+
+	t.mu.Lock()
+	if t.ws != nil {
+		t.ws.Close()
+	}
+	t.ws = ws
+	t.mu.Unlock()
+
+	if t.username == "" {
+		t.userId, t.username = me.Id, me.Username
+	}
+	return me.Username, nil
+}
+
+func (t *Transport) conn() (*websocket.Conn, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.ws == nil {
+		return nil, errors.New("Mattermost connection is closed")
+	}
+	return t.ws, nil
+}
+
+// Receive returns the next command to the bot, other events are skipped.
+func (t *Transport) Receive() (bot.Message, error) {
+	for {
+		ws, err := t.conn()
+		if err != nil {
+			return bot.Message{}, err
+		}
+		var e Event
+		if err := websocket.JSON.Receive(ws, &e); err != nil {
+			return bot.Message{}, err
+		}
+		if e.SeqReply == 1 && e.Status != "OK" {
+			return bot.Message{}, errors.New("Mattermost authentication failed")
+		}
+		if e.Event != "posted" {
+			continue
+		}
+		var data PostedData
+		var post Post
+		if err := json.Unmarshal(e.Data, &data); err != nil {
+			fmt.Fprintf(os.Stderr, "illegal posted event: %v\n", err)
+			continue
+		}
+		if err := json.Unmarshal([]byte(data.Post), &post); err != nil {
+			fmt.Fprintf(os.Stderr, "illegal post: %v\n", err)
+			continue
+		}
+		if text, ok := t.command(post, data.ChannelType); ok {
+			return bot.Message{
+				Channel:  post.ChannelId,
+				User:     strings.TrimPrefix(data.SenderName, "@"),
+				Text:     text,
+				Id:       post.Id,
+				ThreadId: post.RootId,
+				Direct:   data.ChannelType == "D",
+			}, nil
+		}
+	}
+}
+
+// command returns text of command without mention of the bot if the post
+// is addressed to the bot. Commands in direct messages may be without mention.
+func (t *Transport) command(p Post, channelType string) (string, bool) {
+	if p.Type != "" || p.UserId == t.userId {
+		// system posts and the own ones are skipped
+		return "", false
+	}
+	text := strings.TrimSpace(p.Message)
+	mention := "@" + t.username
+	if len(text) >= len(mention) && strings.EqualFold(text[:len(mention)], mention) {
+		return strings.TrimSpace(text[len(mention):]), true
+	}
+	if channelType == "D" {
+		return text, true
+	}
+	return "", false
+}
+
+func (t *Transport) Send(msg bot.Message) error {
+	return t.call("POST", "posts", Post{
+		ChannelId: msg.Channel,
+		RootId:    msg.ThreadId,
+		Message:   msg.Text,
+	}, nil)
+}
+
+// Close closes websocket connection, blocked Receive returns error.
+func (t *Transport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.ws == nil {
+		return nil
+	}
+	err := t.ws.Close()
+	t.ws = nil
+	return err
+}
+
+// call sends the request with JSON arguments to the path of REST API
+// and unmarshals the response into resp if it's not nil.
+func (t *Transport) call(method, path string, args interface{}, resp interface{}) error {
+	var body []byte
+	if args != nil {
+		var err error
+		if body, err = json.Marshal(args); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, t.apiURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+t.cfg.Token)
+	r, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	if r.StatusCode >= 300 {
+		var respErr ResponseError
+		if json.Unmarshal(data, &respErr) == nil && respErr.Message != "" {
+			return fmt.Errorf("Mattermost error: %s", respErr.Message)
+		}
+		return fmt.Errorf("%s %s request failed with code %d", method, path, r.StatusCode)
+	}
+	if resp != nil {
+		return json.Unmarshal(data, resp)
+	}
+	return nil
+}
+
+func (t *Transport) Bold(text string) string {
+	return "**" + text + "**"
+}
+
+func (t *Transport) Italic(text string) string {
+	return "*" + text + "*"
+}
+
+func (t *Transport) Quote(line string) string {
+	return "> " + line
+}
+
+func (t *Transport) Mention(user string) string {
+	return "@" + user
+}
+
+func (t *Transport) Command(text string) string {
+	return "@" + t.username + " " + text
+}
+
+func (t *Transport) DirectCommand(text string) string {
+	return "`" + text + "`"
+}
@@ -0,0 +1,128 @@
+package mattermost
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/austinov/rocker-bot/bot"
+	"github.com/austinov/rocker-bot/config"
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/websocket"
+)
+
+// stubMattermost is a stub of Mattermost's websocket and REST API,
+// the websocket sends the events after authentication.
+type stubMattermost struct {
+	events []Event
+	auth   chan Action
+	posts  chan Post
+}
+
+func posted(channelType, sender string, p Post) Event {
+	post, _ := json.Marshal(p)
+	data, _ := json.Marshal(PostedData{ChannelType: channelType, Post: string(post), SenderName: sender})
+	return Event{Event: "posted", Data: data, Broadcast: Broadcast{ChannelId: p.ChannelId}}
+}
+
+func (s *stubMattermost) websocket(ws *websocket.Conn) {
+	var auth Action
+	if err := websocket.JSON.Receive(ws, &auth); err != nil {
+		return
+	}
+	s.auth <- auth
+	websocket.JSON.Send(ws, Event{Status: "OK", SeqReply: auth.Seq})
+	for _, e := range s.events {
+		websocket.JSON.Send(ws, e)
+	}
+	// wait for close
+	var e Event
+	websocket.JSON.Receive(ws, &e)
+}
+
+func (s *stubMattermost) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/api/v4/websocket" {
+		websocket.Handler(s.websocket).ServeHTTP(w, r)
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer xxx" {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"id": "api.context.session_expired.app_error", "message": "Invalid or expired session"}`))
+		return
+	}
+	switch r.URL.Path {
+	case "/api/v4/users/me":
+		json.NewEncoder(w).Encode(User{Id: "B1", Username: "rocker"})
+	case "/api/v4/posts":
+		var p Post
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &p)
+		s.posts <- p
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(p)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	stub := &stubMattermost{
+		events: []Event{
+			{Event: "hello"},
+			// posts without mention, system and own posts are skipped
+			posted("O", "@user", Post{Id: "P1", ChannelId: "C1", UserId: "U1", Message: "events of Metallica"}),
+			posted("O", "@user", Post{Id: "P2", ChannelId: "C1", UserId: "U1", Message: "@rocker joined", Type: "system_join_channel"}),
+			posted("D", "@rocker", Post{Id: "P3", ChannelId: "D1", UserId: "B1", Message: "help"}),
+			posted("O", "@user", Post{Id: "P4", ChannelId: "C1", UserId: "U1", Message: "@Rocker events of Metallica", RootId: "P0"}),
+			posted("D", "@user", Post{Id: "P5", ChannelId: "D1", UserId: "U1", Message: " following"}),
+		},
+		auth:  make(chan Action, 1),
+		posts: make(chan Post, 1),
+	}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	tr := New(config.MattermostConfig{URL: server.URL, Token: "xxx"})
+	username, err := tr.Connect()
+	assert.NoError(t, err)
+	assert.Equal(t, "rocker", username)
+	auth := <-stub.auth
+	assert.Equal(t, "authentication_challenge", auth.Action)
+	assert.Equal(t, map[string]interface{}{"token": "xxx"}, auth.Data)
+
+	m, err := tr.Receive()
+	assert.NoError(t, err)
+	assert.Equal(t, bot.Message{Channel: "C1", User: "user", Text: "events of Metallica", Id: "P4", ThreadId: "P0"}, m)
+	m, err = tr.Receive()
+	assert.NoError(t, err)
+	assert.Equal(t, bot.Message{Channel: "D1", User: "user", Text: "following", Id: "P5", Direct: true}, m)
+
+	assert.NoError(t, tr.Send(bot.Message{Channel: "C1", Text: "**Metallica**", ThreadId: "P0"}))
+	assert.Equal(t, Post{ChannelId: "C1", RootId: "P0", Message: "**Metallica**"}, <-stub.posts)
+
+	assert.Equal(t, "@rocker events of Metallica", tr.Command("events of Metallica"))
+	assert.Equal(t, "@user", tr.Mention("user"))
+
+	// close stops receiving
+	received := make(chan error, 1)
+	go func() {
+		_, err := tr.Receive()
+		received <- err
+	}()
+	assert.NoError(t, tr.Close())
+	select {
+	case err := <-received:
+		assert.Error(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("receive is not stopped by close")
+	}
+
+	// token is checked on connect
+	tr = New(config.MattermostConfig{URL: server.URL, Token: "yyy"})
+	_, err = tr.Connect()
+	assert.EqualError(t, err, "Mattermost error: Invalid or expired session")
+}
@@ -0,0 +1,56 @@
+package mattermost
+
+import "encoding/json"
+
+// Event is an event of websocket connection.
+type Event struct {
+	Event     string          `json:"event"`
+	Data      json.RawMessage `json:"data"`
+	Broadcast Broadcast       `json:"broadcast"`
+	Seq       int64           `json:"seq"`
+	// Status and SeqReply are set for responses to actions
+	Status   string `json:"status"`
+	SeqReply int64  `json:"seq_reply"`
+}
+
+type Broadcast struct {
+	ChannelId string `json:"channel_id"`
+}
+
+// PostedData is data of posted event, post is JSON encoded.
+type PostedData struct {
+	ChannelType string `json:"channel_type"`
+	Post        string `json:"post"`
+	SenderName  string `json:"sender_name"`
+}
+
+// Action is a request of websocket connection, e.g. authentication.
+type Action struct {
+	Seq    int64       `json:"seq"`
+	Action string      `json:"action"`
+	Data   interface{} `json:"data"`
+}
+
+type AuthenticationData struct {
+	Token string `json:"token"`
+}
+
+type Post struct {
+	Id        string `json:"id,omitempty"`
+	ChannelId string `json:"channel_id"`
+	UserId    string `json:"user_id,omitempty"`
+	RootId    string `json:"root_id,omitempty"`
+	Message   string `json:"message"`
+	Type      string `json:"type,omitempty"`
+}
+
+type User struct {
+	Id       string `json:"id"`
+	Username string `json:"username"`
+}
+
+// ResponseError is an error response of REST API.
+type ResponseError struct {
+	Id      string `json:"id"`
+	Message string `json:"message"`
+}
@@ -12,12 +12,26 @@ type Query struct {
 	Command string
 	Band    string
 	City    string
+	Country string
+	Venue   string
+	Genre   string
 	From    int64
 	To      int64
 }
 
 func (q Query) IsValid() bool {
-	return q.Command != "" && (q.Band != "" || q.City != "")
+	switch q.Command {
+	case "following", "watching":
+		return true
+	case "follow", "unfollow":
+		return q.Band != ""
+	case "watch", "unwatch":
+		return q.City != ""
+	case "ical":
+		return (q.Band != "") != (q.City != "")
+	}
+	return q.Command != "" &&
+		(q.Band != "" || q.City != "" || q.Country != "" || q.Venue != "" || q.Genre != "")
 }
 
 const (
@@ -27,11 +41,17 @@ const (
 	since
 	till
 	between
+	country
+	venue
+	genre
 )
 
 var paramKinds = map[byte]string{
 	band:    " of ",
 	city:    " in ",
+	country: " in country ",
+	venue:   " at venue ",
+	genre:   " of genre ",
 	at:      " at ",
 	since:   " since ",
 	till:    " till ",
@@ -44,21 +64,49 @@ type param struct {
 	value string
 }
 
+// Parse parses the message to the bot, the first word of the text is mention of the bot.
 func Parse(text string) Query {
+	return ParseCommand(afterFields(text, 1))
+}
+
+// ParseCommand parses the command without mention of the bot, e.g. "events of Metallica".
+func ParseCommand(text string) Query {
+	fields := strings.Fields(text)
+	if len(fields) > 0 {
+		// the rest of text is band's or city's name, it may contain
+		// params' words (e.g. System of a Down)
+		switch fields[0] {
+		case "follow", "unfollow":
+			return Query{
+				Command: fields[0],
+				Band:    afterFields(text, 1),
+			}
+		case "watch", "unwatch":
+			return Query{
+				Command: fields[0],
+				City:    afterFields(text, 1),
+			}
+		case "ical":
+			q := Query{Command: fields[0]}
+			if len(fields) > 1 && fields[1] == "of" {
+				q.Band = afterFields(text, 2)
+			} else if len(fields) > 1 && fields[1] == "in" {
+				q.City = afterFields(text, 2)
+			}
+			return q
+		}
+	}
+
 	m := strings.ToLower(text)
 
 	l := list.New()
-	addToList(l, band, strings.Index(m, paramKinds[band]))
-	addToList(l, city, strings.Index(m, paramKinds[city]))
-	addToList(l, at, strings.Index(m, paramKinds[at]))
-	addToList(l, since, strings.Index(m, paramKinds[since]))
-	addToList(l, till, strings.Index(m, paramKinds[till]))
-	addToList(l, between, strings.Index(m, paramKinds[between]))
+	for kind := range paramKinds {
+		addToList(l, kind, indexParam(m, kind))
+	}
 
 	query := Query{}
-	fields := strings.Fields(text)
-	if len(fields) > 1 {
-		query.Command = fields[1]
+	if len(fields) > 0 {
+		query.Command = fields[0]
 	}
 	for e := l.Front(); e != nil; e = e.Next() {
 		p := e.Value.(param)
@@ -72,12 +120,31 @@ func Parse(text string) Query {
 	return query
 }
 
+// afterFields returns the text after n first fields without surrounding spaces.
+func afterFields(text string, n int) string {
+	for i := 0; i < n; i++ {
+		text = strings.TrimSpace(text)
+		if idx := strings.IndexAny(text, " \t\n"); idx != -1 {
+			text = text[idx:]
+		} else {
+			return ""
+		}
+	}
+	return strings.TrimSpace(text)
+}
+
 func fillQuery(q *Query, p param) {
 	switch p.kind {
 	case band:
 		q.Band = p.value
 	case city:
 		q.City = p.value
+	case country:
+		q.Country = p.value
+	case venue:
+		q.Venue = p.value
+	case genre:
+		q.Genre = p.value
 	case at:
 		q.From, q.To = parseAtDate(p.value)
 	case since:
@@ -144,6 +211,37 @@ func parseBetweenDates(d string) (int64, int64) {
 	return 0, 0
 }
 
+// indexParam returns index of the first param of the kind in the text.
+// Params which are prefixes of other ones (e.g. " in " and " in country ")
+// are skipped at the positions of the longer params.
+func indexParam(text string, kind byte) int {
+	prefix := paramKinds[kind]
+	for offset := 0; offset < len(text); {
+		pos := strings.Index(text[offset:], prefix)
+		if pos == -1 {
+			return -1
+		}
+		pos += offset
+		if !isLongerParam(text[pos:], kind) {
+			return pos
+		}
+		offset = pos + len(prefix)
+	}
+	return -1
+}
+
+// isLongerParam reports whether the text begins with a param which
+// is longer than the param of the kind and starts with it.
+func isLongerParam(text string, kind byte) bool {
+	prefix := paramKinds[kind]
+	for k, p := range paramKinds {
+		if k != kind && len(p) > len(prefix) && strings.HasPrefix(p, prefix) && strings.HasPrefix(text, p) {
+			return true
+		}
+	}
+	return false
+}
+
 func addToList(l *list.List, kind byte, pos int) {
 	if pos == -1 {
 		return
@@ -152,6 +152,64 @@ func TestParser(t *testing.T) {
 				To:      1410566399,
 			},
 		},
+		{
+			text: "@bot events of Slayer in country Germany",
+			expQuery: Query{
+				Command: "events",
+				Band:    "Slayer",
+				Country: "Germany",
+			},
+		},
+		{
+			text: "@bot events in country Germany of Slayer since 12.12.2009",
+			expQuery: Query{
+				Command: "events",
+				Band:    "Slayer",
+				Country: "Germany",
+				From:    1260576000,
+			},
+		},
+		{
+			text: "@bot events of Slayer in Berlin in country Germany",
+			expQuery: Query{
+				Command: "events",
+				Band:    "Slayer",
+				City:    "Berlin",
+				Country: "Germany",
+			},
+		},
+		{
+			text: "@bot events at venue Tavastia",
+			expQuery: Query{
+				Command: "events",
+				Venue:   "Tavastia",
+			},
+		},
+		{
+			text: "@bot events at venue Tavastia at 15 Dec 2009",
+			expQuery: Query{
+				Command: "events",
+				Venue:   "Tavastia",
+				From:    1260835200,
+				To:      1260921599,
+			},
+		},
+		{
+			text: "@bot events of genre doom in Berlin",
+			expQuery: Query{
+				Command: "events",
+				Genre:   "doom",
+				City:    "Berlin",
+			},
+		},
+		{
+			text: "@bot events in Oslo of genre black metal",
+			expQuery: Query{
+				Command: "events",
+				Genre:   "black metal",
+				City:    "Oslo",
+			},
+		},
 		{
 			text: "@bot events in Moscow of A Day to remember at 12.12.2009",
 			expQuery: Query{
@@ -219,6 +277,82 @@ func TestParserValid(t *testing.T) {
 			},
 			expValid: false,
 		},
+		{
+			text: "@bot follow System of a Down ",
+			expQuery: Query{
+				Command: "follow",
+				Band:    "System of a Down",
+			},
+			expValid: true,
+		},
+		{
+			text: "@bot unfollow  Metallica",
+			expQuery: Query{
+				Command: "unfollow",
+				Band:    "Metallica",
+			},
+			expValid: true,
+		},
+		{
+			text: "@bot follow",
+			expQuery: Query{
+				Command: "follow",
+			},
+			expValid: false,
+		},
+		{
+			text: "@bot following",
+			expQuery: Query{
+				Command: "following",
+			},
+			expValid: true,
+		},
+		{
+			text: "@bot watch Sergiev Posad",
+			expQuery: Query{
+				Command: "watch",
+				City:    "Sergiev Posad",
+			},
+			expValid: true,
+		},
+		{
+			text: "@bot unwatch Helsinki",
+			expQuery: Query{
+				Command: "unwatch",
+				City:    "Helsinki",
+			},
+			expValid: true,
+		},
+		{
+			text: "@bot watching",
+			expQuery: Query{
+				Command: "watching",
+			},
+			expValid: true,
+		},
+		{
+			text: "@bot ical of System of a Down",
+			expQuery: Query{
+				Command: "ical",
+				Band:    "System of a Down",
+			},
+			expValid: true,
+		},
+		{
+			text: "@bot ical in  St Petersburg ",
+			expQuery: Query{
+				Command: "ical",
+				City:    "St Petersburg",
+			},
+			expValid: true,
+		},
+		{
+			text: "@bot ical Metallica",
+			expQuery: Query{
+				Command: "ical",
+			},
+			expValid: false,
+		},
 		{
 			text: "@bot events not valid query",
 			expQuery: Query{
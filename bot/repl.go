@@ -0,0 +1,89 @@
+package bot
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/austinov/rocker-bot/config"
+	"github.com/austinov/rocker-bot/store"
+)
+
+const replHelp = `Type commands like in direct messages to the bot, e.g. events of Metallica.
+Commands which change the db (follow, unfollow, watch, unwatch) aren't run.
+:query toggles printing of the parsed query as JSON, :quit exits.
+`
+
+var errREPL = errors.New("REPL doesn't receive messages")
+
+// replDenied are commands which change subscriptions and watches in the db,
+// they aren't run by REPL.
+var replDenied = map[string]bool{
+	"follow":   true,
+	"unfollow": true,
+	"watch":    true,
+	"unwatch":  true,
+}
+
+// terminal is a markup of replies printed by REPL.
+type terminal struct{}
+
+func (t terminal) Connect() (string, error)   { return "repl", nil }
+func (t terminal) Receive() (Message, error)  { return Message{}, errREPL }
+func (t terminal) Send(msg Message) error     { return nil }
+func (t terminal) Close() error               { return nil }
+func (t terminal) Bold(text string) string    { return "*" + text + "*" }
+func (t terminal) Italic(text string) string  { return "_" + text + "_" }
+func (t terminal) Quote(line string) string   { return "  " + line }
+func (t terminal) Mention(user string) string { return "@" + user }
+func (t terminal) Command(text string) string { return text }
+
+// REPL reads commands from in line by line and writes replies of the bot to out,
+// the replies are made against the dao like in direct messages. The command may start
+// with mention of the bot. Commands which change the db (follow, watch, etc.)
+// are refused. If dumping of queries is on, the parsed query is written
+// as JSON before the reply. It returns when in is read or :quit is entered.
+func REPL(cfg config.BotConfig, dao store.Dao, in io.Reader, out io.Writer, dumpQuery bool) error {
+	b := New(cfg, dao, terminal{})
+	fmt.Fprint(out, replHelp)
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(out, "> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return scanner.Err()
+		}
+		text := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(text, "@") {
+			text = afterFields(text, 1)
+		}
+		switch text {
+		case "":
+			continue
+		case ":quit":
+			return nil
+		case ":query":
+			dumpQuery = !dumpQuery
+			if dumpQuery {
+				fmt.Fprintln(out, "Printing of queries is on.")
+			} else {
+				fmt.Fprintln(out, "Printing of queries is off.")
+			}
+			continue
+		}
+		query := ParseCommand(text)
+		if dumpQuery {
+			q, _ := json.MarshalIndent(query, "", "  ")
+			fmt.Fprintf(out, "%s\n", q)
+		}
+		if replDenied[query.Command] {
+			fmt.Fprintf(out, "Command %s changes the db, it isn't available in REPL.\n", query.Command)
+			continue
+		}
+		reply := b.Reply(Message{Channel: "repl", User: "repl", Text: text, Direct: true})
+		fmt.Fprintln(out, strings.TrimRight(reply.Text, "\n"))
+	}
+}
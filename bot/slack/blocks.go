@@ -0,0 +1,67 @@
+package slack
+
+import (
+	"strings"
+
+	"github.com/austinov/rocker-bot/bot"
+	"github.com/austinov/rocker-bot/store"
+)
+
+const (
+	// blocksFormat renders events as Block Kit sections
+	blocksFormat = "blocks"
+	// blocksLimit is a maximum number of events rendered as blocks,
+	// Slack accepts up to 50 blocks in message and each event takes 3 ones
+	blocksLimit = 15
+)
+
+// formatBlocks returns Block Kit blocks of the events: section with title,
+// date, city and venue, poster of the event and button to its details.
+func formatBlocks(header string, events []store.Event) []Block {
+	blocks := make([]Block, 0, 1+3*len(events))
+	blocks = append(blocks, textBlock(header))
+	for _, e := range events {
+		blocks = append(blocks, Block{Type: "divider"})
+
+		section := Block{
+			Type: "section",
+			Text: &TextObject{Type: "mrkdwn", Text: "*" + e.Title + "*"},
+		}
+		section.Fields = append(section.Fields, TextObject{Type: "mrkdwn", Text: "*Date*\n" + bot.FormatDates(e)})
+		if e.City != "" {
+			section.Fields = append(section.Fields, TextObject{Type: "mrkdwn", Text: "*City*\n" + e.City})
+		}
+		if e.Venue != "" {
+			section.Fields = append(section.Fields, TextObject{Type: "mrkdwn", Text: "*Venue*\n" + e.Venue})
+		}
+		// Slack loads images by absolute urls only
+		if strings.HasPrefix(e.Img, "http") {
+			section.Accessory = &BlockElement{
+				Type:     "image",
+				ImageUrl: e.Img,
+				AltText:  e.Title,
+			}
+		}
+		blocks = append(blocks, section)
+
+		if e.Link != "" {
+			blocks = append(blocks, Block{
+				Type: "actions",
+				Elements: []BlockElement{{
+					Type: "button",
+					Text: &TextObject{Type: "plain_text", Text: "Details"},
+					Url:  e.Link,
+				}},
+			})
+		}
+	}
+	return blocks
+}
+
+// textBlock returns section block with the mrkdwn text.
+func textBlock(text string) Block {
+	return Block{
+		Type: "section",
+		Text: &TextObject{Type: "mrkdwn", Text: text},
+	}
+}
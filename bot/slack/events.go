@@ -0,0 +1,206 @@
+package slack
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/austinov/rocker-bot/config"
+)
+
+const (
+	defaultEventsPath = "/slack/events"
+	// maxRequestAge is a maximum age of Slack's request,
+	// older requests are rejected to prevent replay attacks
+	maxRequestAge = 5 * time.Minute
+	// maxRequestSize is a maximum size of Slack's request body,
+	// larger bodies aren't read before the signature is verified
+	maxRequestSize = 1 << 20
+	// eventsQueueSize is a number of received messages waiting for processing
+	eventsQueueSize = 100
+)
+
+// eventsTransport receives mentions and direct messages on HTTP endpoint of
+// Slack's Events API and sends replies by chat.postMessage method.
+type eventsTransport struct {
+	*webAPI
+	cfg      config.BotConfig
+	mux      *http.ServeMux
+	messages chan Message
+	mu       sync.Mutex    // it guards ln, done and err
+	ln       net.Listener  // listener of HTTP server
+	done     chan struct{} // it's closed when HTTP server is stopped
+	err      error         // error of HTTP server
+}
+
+func newEventsTransport(cfg config.BotConfig) *eventsTransport {
+	if cfg.Events.Path == "" {
+		cfg.Events.Path = defaultEventsPath
+	}
+	t := &eventsTransport{
+		webAPI:   newWebAPI(cfg.Token),
+		cfg:      cfg,
+		mux:      http.NewServeMux(),
+		messages: make(chan Message, eventsQueueSize),
+	}
+	t.mux.Handle(cfg.Events.Path, t)
+	return t
+}
+
+// connect checks the token and starts HTTP server to receive events
+// if it's not running.
+func (t *eventsTransport) connect() (string, error) {
+	var auth ResponseAuthTest
+	if err := t.call("auth.test", nil, &auth); err != nil {
+		return "", err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.done != nil {
+		select {
+		case <-t.done:
+		default:
+			// server is running
+			return auth.UserId, nil
+		}
+	}
+	ln, err := net.Listen("tcp", t.cfg.Events.Listen)
+	if err != nil {
+		return "", err
+	}
+	done := make(chan struct{})
+	t.ln = ln
+	t.done = done
+	go func() {
+		err := http.Serve(ln, t.mux)
+		t.mu.Lock()
+		t.err = err
+		t.mu.Unlock()
+		close(done)
+	}()
+	return auth.UserId, nil
+}
+
+func (t *eventsTransport) receive() (Message, error) {
+	t.mu.Lock()
+	done := t.done
+	t.mu.Unlock()
+	select {
+	case m := <-t.messages:
+		return m, nil
+	case <-done:
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		return Message{}, fmt.Errorf("events endpoint is stopped: %v", t.err)
+	}
+}
+
+func (t *eventsTransport) send(msg Message) error {
+	return t.postMessage(msg)
+}
+
+// close stops HTTP server, received messages are not processed.
+func (t *eventsTransport) close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.ln == nil {
+		return nil
+	}
+	err := t.ln.Close()
+	t.ln = nil
+	return err
+}
+
+// ServeHTTP handles requests of Events API. Mentions of the bot
+// are passed to the bot as messages, they are dropped if the bot
+// is too busy to process them.
+func (t *eventsTransport) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := verifySignature(t.cfg.Events.SigningSecret, r.Header, body, time.Now()); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	var cb EventCallback
+	if err := json.Unmarshal(body, &cb); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	switch cb.Type {
+	case "url_verification":
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(cb.Challenge))
+	case "event_callback":
+		// Slack retries events which aren't acknowledged in 3 seconds,
+		// the retries are skipped to not reply twice
+		w.WriteHeader(http.StatusOK)
+		if r.Header.Get("X-Slack-Retry-Num") != "" {
+			return
+		}
+		if m, ok := callbackMessage(cb.Event); ok {
+			select {
+			case t.messages <- m:
+			default:
+				fmt.Fprintf(os.Stderr, "events queue is full, event %s is dropped\n", cb.EventId)
+			}
+		}
+	default:
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// callbackMessage returns message of the event if it's addressed to the bot,
+// i.e. it's mention of the bot or direct message to it.
+func callbackMessage(e CallbackEvent) (Message, bool) {
+	switch {
+	case e.Type == "app_mention":
+	case e.Type == "message" && e.ChannelType == "im" && e.Subtype == "" && e.BotId == "":
+	default:
+		return Message{}, false
+	}
+	return Message{
+		Type:     "message",
+		Channel:  e.Channel,
+		User:     e.User,
+		Text:     e.Text,
+		Ts:       e.Ts,
+		ThreadTs: e.ThreadTs,
+	}, true
+}
+
+// verifySignature verifies that the request is signed by Slack with the secret.
+func verifySignature(secret string, header http.Header, body []byte, now time.Time) error {
+	ts := header.Get("X-Slack-Request-Timestamp")
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return errors.New("Invalid request timestamp")
+	}
+	if age := now.Sub(time.Unix(sec, 0)); age > maxRequestAge || age < -maxRequestAge {
+		return errors.New("Request is expired")
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + ts + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(header.Get("X-Slack-Signature"))) {
+		return errors.New("Invalid request signature")
+	}
+	return nil
+}
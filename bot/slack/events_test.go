@@ -0,0 +1,136 @@
+package slack
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/austinov/rocker-bot/config"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSecret = "secret"
+
+func signedRequest(body string, now time.Time) *http.Request {
+	ts := strconv.FormatInt(now.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(testSecret))
+	mac.Write([]byte("v0:" + ts + ":" + body))
+	r := httptest.NewRequest("POST", defaultEventsPath, bytes.NewBufferString(body))
+	r.Header.Set("X-Slack-Request-Timestamp", ts)
+	r.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return r
+}
+
+func TestVerifySignature(t *testing.T) {
+	now := time.Now()
+	r := signedRequest("body", now)
+	assert.NoError(t, verifySignature(testSecret, r.Header, []byte("body"), now))
+	assert.Error(t, verifySignature(testSecret, r.Header, []byte("another body"), now))
+	assert.Error(t, verifySignature("another secret", r.Header, []byte("body"), now))
+	assert.Error(t, verifySignature(testSecret, r.Header, []byte("body"), now.Add(maxRequestAge+time.Second)))
+	r.Header.Del("X-Slack-Request-Timestamp")
+	assert.Error(t, verifySignature(testSecret, r.Header, []byte("body"), now))
+}
+
+func TestEventsTransport(t *testing.T) {
+	var posted PostMessage
+	slack := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer xxx", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/auth.test":
+			w.Write([]byte(`{"ok":true,"user_id":"UBOT"}`))
+		case "/chat.postMessage":
+			body, _ := ioutil.ReadAll(r.Body)
+			assert.NoError(t, json.Unmarshal(body, &posted))
+			w.Write([]byte(`{"ok":true}`))
+		default:
+			w.Write([]byte(`{"ok":false,"error":"unknown_method"}`))
+		}
+	}))
+	defer slack.Close()
+
+	tr := newEventsTransport(config.BotConfig{
+		Token: "xxx",
+		Events: config.EventsConfig{
+			Listen:        "127.0.0.1:0",
+			SigningSecret: testSecret,
+		},
+	})
+	tr.apiURL = slack.URL + "/"
+
+	id, err := tr.connect()
+	assert.NoError(t, err)
+	assert.Equal(t, "UBOT", id)
+
+	// url verification
+	w := httptest.NewRecorder()
+	tr.ServeHTTP(w, signedRequest(`{"type":"url_verification","challenge":"abc"}`, time.Now()))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "abc", w.Body.String())
+
+	// unsigned request
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest("POST", defaultEventsPath, bytes.NewBufferString(`{"type":"url_verification","challenge":"abc"}`))
+	tr.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// too large request isn't read
+	w = httptest.NewRecorder()
+	tr.ServeHTTP(w, signedRequest(`{"type":"url_verification","challenge":"`+strings.Repeat("a", maxRequestSize)+`"}`, time.Now()))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// mention of bot
+	w = httptest.NewRecorder()
+	tr.ServeHTTP(w, signedRequest(`{"type":"event_callback","event":{"type":"app_mention",`+
+		`"user":"U1","channel":"C1","text":"<@UBOT> events of Metallica"}}`, time.Now()))
+	assert.Equal(t, http.StatusOK, w.Code)
+	m, err := tr.receive()
+	assert.NoError(t, err)
+	assert.Equal(t, Message{Type: "message", Channel: "C1", User: "U1", Text: "<@UBOT> events of Metallica"}, m)
+
+	// retries are acknowledged and skipped
+	w = httptest.NewRecorder()
+	r = signedRequest(`{"type":"event_callback","event_id":"Ev1","event":{"type":"app_mention",`+
+		`"user":"U1","channel":"C1","text":"<@UBOT> events of Metallica"}}`, time.Now())
+	r.Header.Set("X-Slack-Retry-Num", "1")
+	tr.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, tr.messages, 0)
+
+	// events are acknowledged and dropped if the queue is full
+	for i := 0; i <= eventsQueueSize; i++ {
+		w = httptest.NewRecorder()
+		tr.ServeHTTP(w, signedRequest(`{"type":"event_callback","event":{"type":"app_mention",`+
+			`"user":"U1","channel":"C1","text":"<@UBOT> help"}}`, time.Now()))
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+	assert.Len(t, tr.messages, eventsQueueSize)
+
+	assert.NoError(t, tr.send(Message{Type: "message", Channel: "C1", Text: "reply"}))
+	assert.Equal(t, PostMessage{Channel: "C1", Text: "reply"}, posted)
+}
+
+func TestCallbackMessage(t *testing.T) {
+	m, ok := callbackMessage(CallbackEvent{Type: "app_mention", Channel: "C1", User: "U1", Text: "<@UBOT> help", Ts: "1.1", ThreadTs: "1.0"})
+	assert.True(t, ok)
+	assert.Equal(t, Message{Type: "message", Channel: "C1", User: "U1", Text: "<@UBOT> help", Ts: "1.1", ThreadTs: "1.0"}, m)
+
+	m, ok = callbackMessage(CallbackEvent{Type: "message", ChannelType: "im", Channel: "D1", User: "U1", Text: "help"})
+	assert.True(t, ok)
+	assert.Equal(t, "D1", m.Channel)
+
+	// messages in channels are received as mentions, messages of bots are skipped
+	_, ok = callbackMessage(CallbackEvent{Type: "message", ChannelType: "channel", Channel: "C1", Text: "help"})
+	assert.False(t, ok)
+	_, ok = callbackMessage(CallbackEvent{Type: "message", ChannelType: "im", Channel: "D1", BotId: "B1", Text: "help"})
+	assert.False(t, ok)
+}
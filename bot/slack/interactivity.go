@@ -0,0 +1,182 @@
+package slack
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/austinov/rocker-bot/bot"
+)
+
+const (
+	defaultInteractivityPath = "/slack/interactivity"
+	// eventsCallbackId is a callback id of buttons attached to list of events
+	eventsCallbackId = "events"
+)
+
+// pageState is a state of events list, it's kept in value of page button.
+type pageState struct {
+	Query  bot.Query `json:"q"`
+	Offset int       `json:"o"`
+}
+
+// pageButtons returns attachment with buttons to load the previous and
+// the next events of the page. The fallback is a command to load the next
+// events for clients which don't support buttons.
+func pageButtons(p *bot.Page) []Attachment {
+	actions := make([]Action, 0, 2)
+	if p.Offset > 0 {
+		prev := p.Offset - p.Limit
+		if prev < 0 {
+			prev = 0
+		}
+		actions = append(actions, pageAction("previous", "Previous", p.Query, prev))
+	}
+	fallback := "No more events"
+	if p.More() {
+		actions = append(actions, pageAction("next", fmt.Sprintf("Next %d", p.Limit), p.Query, p.Offset+p.Limit))
+		fallback = p.Footer
+	}
+	return []Attachment{{
+		Fallback:   fallback,
+		CallbackId: eventsCallbackId,
+		Type:       "default",
+		Actions:    actions,
+	}}
+}
+
+func pageAction(name, text string, q bot.Query, offset int) Action {
+	value, _ := json.Marshal(pageState{Query: q, Offset: offset})
+	return Action{
+		Name:  name,
+		Text:  text,
+		Type:  "button",
+		Value: string(value),
+	}
+}
+
+// enableInteractivity makes the connection to pass callbacks of
+// interactive messages to the bot.
+func (t *Transport) enableInteractivity() {
+	path := t.cfg.Interactivity.Path
+	if path == "" {
+		path = defaultInteractivityPath
+	}
+	if c, ok := t.c.(*socketTransport); ok {
+		c.interact = t.interact
+		return
+	}
+	t.handle(path, &interactivityHandler{
+		secret:   t.signingSecret(),
+		interact: t.interact,
+	})
+}
+
+// handle registers the handler of Slack's requests for the path. The handler
+// is served with Events API by events connection or on listen address of
+// interactivity configuration by rtm one. Socket connection receives requests
+// through websocket, so it doesn't serve handlers.
+func (t *Transport) handle(path string, h http.Handler) {
+	switch c := t.c.(type) {
+	case *eventsTransport:
+		c.mux.Handle(path, h)
+	case *rtmTransport:
+		if c.endpoints == nil {
+			c.endpoints = http.NewServeMux()
+		}
+		c.endpoints.Handle(path, h)
+	}
+}
+
+// signingSecret returns secret to verify Slack's requests.
+func (t *Transport) signingSecret() string {
+	if t.cfg.Transport == "events" {
+		return t.cfg.Events.SigningSecret
+	}
+	return t.cfg.Interactivity.SigningSecret
+}
+
+// interact handles click on button attached to list of events
+// and returns the page of events to replace the original message.
+func (t *Transport) interact(cb InteractiveCallback) (InteractiveResponse, error) {
+	if cb.CallbackId != eventsCallbackId || len(cb.Actions) == 0 {
+		return InteractiveResponse{}, fmt.Errorf("Unknown callback %s", cb.CallbackId)
+	}
+	var state pageState
+	if err := json.Unmarshal([]byte(cb.Actions[0].Value), &state); err != nil {
+		return InteractiveResponse{}, fmt.Errorf("Illegal value of action: %v", err)
+	}
+	if state.Offset < 0 || !state.Query.IsValid() || state.Query.Command != "events" {
+		return InteractiveResponse{}, errors.New("Illegal state of events list")
+	}
+	text, attachments, blocks := t.render(t.replier.Calendar(state.Query, state.Offset))
+	return InteractiveResponse{
+		Text:            text,
+		Attachments:     attachments,
+		Blocks:          blocks,
+		ReplaceOriginal: true,
+	}, nil
+}
+
+// interactivityHandler handles requests of Slack's interactive messages.
+type interactivityHandler struct {
+	secret   string
+	interact func(cb InteractiveCallback) (InteractiveResponse, error)
+}
+
+func (h *interactivityHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := verifySignature(h.secret, r.Header, body, time.Now()); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var cb InteractiveCallback
+	if err := json.Unmarshal([]byte(form.Get("payload")), &cb); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	resp, err := h.interact(cb)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "interactive callback failed with %#v\n", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// respond sends the response to interactive message or slash command by its response url.
+func (a *webAPI) respond(responseUrl string, resp interface{}) error {
+	body, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	r, err := a.client.Post(responseUrl, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+	if r.StatusCode != 200 {
+		return fmt.Errorf("response by %s failed with code %d", responseUrl, r.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package slack
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/austinov/rocker-bot/bot"
+	"github.com/austinov/rocker-bot/config"
+	"github.com/austinov/rocker-bot/store"
+	"github.com/austinov/rocker-bot/store/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+// pageSize is a default number of events in reply of the bot.
+const pageSize = 42
+
+// newPagedTransport returns transport with buttons enabled
+// and the bot replying with pages of events.
+func newPagedTransport(events int) (*Transport, store.Dao) {
+	dao := memory.New(config.DBConfig{Type: "memory"})
+	list := make([]store.Event, events)
+	for i := range list {
+		from := int64(1493856000 + i*86400)
+		list[i] = store.Event{Band: "Metallica", Title: fmt.Sprintf("Metallica %d", i), From: from, To: from, City: "Paris"}
+	}
+	dao.AddBandEvents(list)
+	cfg := config.BotConfig{
+		Token: "xxx",
+		Interactivity: config.InteractivityConfig{
+			Enabled:       true,
+			SigningSecret: testSecret,
+		},
+	}
+	t := New(cfg)
+	t.id = "<@bot>"
+	bot.New(cfg, dao, t)
+	return t, dao
+}
+
+func TestPageButtons(t *testing.T) {
+	tr, dao := newPagedTransport(pageSize + 1)
+	defer dao.Close()
+	q := bot.Query{Command: "events", Band: "Metallica"}
+
+	// the first page has next button only
+	out, attachments, _ := tr.render(tr.replier.Calendar(q, 0))
+	assert.NotContains(t, out, "To load next portion")
+	assert.Len(t, attachments, 1)
+	assert.Equal(t, eventsCallbackId, attachments[0].CallbackId)
+	assert.Contains(t, attachments[0].Fallback, "<@bot> events of Metallica since")
+	actions := attachments[0].Actions
+	assert.Len(t, actions, 1)
+	assert.Equal(t, "Next 42", actions[0].Text)
+	var state pageState
+	assert.NoError(t, json.Unmarshal([]byte(actions[0].Value), &state))
+	assert.Equal(t, pageState{Query: q, Offset: pageSize}, state)
+
+	// the last page has previous button only
+	out, attachments, _ = tr.render(tr.replier.Calendar(q, pageSize))
+	assert.Contains(t, out, "Metallica 42")
+	assert.Len(t, attachments[0].Actions, 1)
+	assert.Equal(t, "previous", attachments[0].Actions[0].Name)
+
+	// short list has no buttons
+	_, attachments, _ = tr.render(tr.replier.Calendar(bot.Query{Command: "events", City: "London"}, 0))
+	assert.Nil(t, attachments)
+}
+
+func TestInteractivityHandler(t *testing.T) {
+	tr, dao := newPagedTransport(pageSize + 1)
+	defer dao.Close()
+	h := &interactivityHandler{secret: testSecret, interact: tr.interact}
+
+	_, attachments, _ := tr.render(tr.replier.Calendar(bot.Query{Command: "events", Band: "Metallica"}, 0))
+	payload, _ := json.Marshal(InteractiveCallback{
+		Type:       "interactive_message",
+		CallbackId: eventsCallbackId,
+		Actions:    attachments[0].Actions,
+		Channel:    CallbackChannel{Id: "C1"},
+	})
+	body := url.Values{"payload": {string(payload)}}.Encode()
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, signedRequest(body, time.Now()))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp InteractiveResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.True(t, resp.ReplaceOriginal)
+	assert.Contains(t, resp.Text, "Metallica 42")
+	assert.Equal(t, "previous", resp.Attachments[0].Actions[0].Name)
+
+	// request is not signed
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", defaultInteractivityPath, nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// unknown callback
+	payload, _ = json.Marshal(InteractiveCallback{CallbackId: "unknown"})
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, signedRequest(url.Values{"payload": {string(payload)}}.Encode(), time.Now()))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,176 @@
+package slack
+
+import "encoding/json"
+
+type Message struct {
+	Id          uint64       `json:"id"`
+	Type        string       `json:"type"`
+	Channel     string       `json:"channel"`
+	Subtype     string       `json:"subtype,omitempty"`
+	User        string       `json:"user,omitempty"`
+	Text        string       `json:"text"`
+	Ts          string       `json:"ts,omitempty"`
+	ThreadTs    string       `json:"thread_ts,omitempty"`
+	Attachments []Attachment `json:"attachments"`
+	Blocks      []Block      `json:"blocks,omitempty"`
+}
+
+type ResponseRtmStart struct {
+	Ok    bool         `json:"ok"`
+	Error string       `json:"error"`
+	Url   string       `json:"url"`
+	Self  ResponseSelf `json:"self"`
+}
+
+type ResponseSelf struct {
+	Id string `json:"id"`
+}
+
+// ResponseAPI is a common part of responses of Slack's Web API methods.
+type ResponseAPI struct {
+	Ok    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+type ResponseAuthTest struct {
+	ResponseAPI
+	UserId string `json:"user_id"`
+}
+
+// PostMessage is a request of chat.postMessage method.
+type PostMessage struct {
+	Channel     string       `json:"channel"`
+	Text        string       `json:"text"`
+	ThreadTs    string       `json:"thread_ts,omitempty"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+	Blocks      []Block      `json:"blocks,omitempty"`
+}
+
+// EventCallback is a request of Slack's Events API.
+type EventCallback struct {
+	Type      string        `json:"type"`
+	Challenge string        `json:"challenge"`
+	EventId   string        `json:"event_id"`
+	Event     CallbackEvent `json:"event"`
+}
+
+// SocketEnvelope is a message of Slack's Socket Mode connection.
+// Payload of events_api envelope is EventCallback.
+type SocketEnvelope struct {
+	Type       string          `json:"type"`
+	EnvelopeId string          `json:"envelope_id"`
+	Reason     string          `json:"reason"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// SocketAck acknowledges the envelope received by Socket Mode connection.
+type SocketAck struct {
+	EnvelopeId string `json:"envelope_id"`
+}
+
+type ResponseConnectionsOpen struct {
+	ResponseAPI
+	Url string `json:"url"`
+}
+
+type CallbackEvent struct {
+	Type        string `json:"type"`
+	Subtype     string `json:"subtype"`
+	User        string `json:"user"`
+	BotId       string `json:"bot_id"`
+	Channel     string `json:"channel"`
+	ChannelType string `json:"channel_type"`
+	Text        string `json:"text"`
+	Ts          string `json:"ts"`
+	ThreadTs    string `json:"thread_ts"`
+}
+
+// InteractiveCallback is a request of Slack's interactive message,
+// e.g. click on button of attachment.
+type InteractiveCallback struct {
+	Type        string          `json:"type"`
+	CallbackId  string          `json:"callback_id"`
+	Actions     []Action        `json:"actions"`
+	Channel     CallbackChannel `json:"channel"`
+	MessageTs   string          `json:"message_ts"`
+	ResponseUrl string          `json:"response_url"`
+}
+
+type CallbackChannel struct {
+	Id string `json:"id"`
+}
+
+// InteractiveResponse is a response to interactive message,
+// it replaces the original message.
+type InteractiveResponse struct {
+	Text            string       `json:"text"`
+	Attachments     []Attachment `json:"attachments"`
+	Blocks          []Block      `json:"blocks,omitempty"`
+	ReplaceOriginal bool         `json:"replace_original"`
+}
+
+// SlashCommand is a request of Slack's slash command. It's received as form
+// by HTTP endpoint or as JSON payload of Socket Mode envelope.
+type SlashCommand struct {
+	Command     string `json:"command"`
+	Text        string `json:"text"`
+	UserId      string `json:"user_id"`
+	ChannelId   string `json:"channel_id"`
+	ResponseUrl string `json:"response_url"`
+}
+
+// CommandResponse is a response to slash command.
+type CommandResponse struct {
+	ResponseType string       `json:"response_type"`
+	Text         string       `json:"text"`
+	Attachments  []Attachment `json:"attachments,omitempty"`
+	Blocks       []Block      `json:"blocks,omitempty"`
+}
+
+type Attachment struct {
+	Text       string   `json:"text"`
+	Fallback   string   `json:"fallback"`
+	CallbackId string   `json:"callback_id"`
+	Color      string   `json:"color"`
+	Type       string   `json:"attachment_type"`
+	Actions    []Action `json:"actions"`
+}
+
+type Action struct {
+	Name  string   `json:"name"`
+	Text  string   `json:"text"`
+	Type  string   `json:"type"`
+	Value string   `json:"value"`
+	Style string   `json:"style"`
+	Cfm   *Confirm `json:"confirm,omitempty"`
+}
+
+type Confirm struct {
+	Title   string `json:"title"`
+	Text    string `json:"text"`
+	Ok      string `json:"ok_text"`
+	Dismiss string `json:"dismiss_text"`
+}
+
+// Block is a block of Slack's Block Kit message.
+type Block struct {
+	Type      string         `json:"type"`
+	Text      *TextObject    `json:"text,omitempty"`
+	Fields    []TextObject   `json:"fields,omitempty"`
+	Accessory *BlockElement  `json:"accessory,omitempty"`
+	Elements  []BlockElement `json:"elements,omitempty"`
+}
+
+type TextObject struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// BlockElement is an element of block, e.g. image or button.
+type BlockElement struct {
+	Type     string      `json:"type"`
+	Text     *TextObject `json:"text,omitempty"`
+	Url      string      `json:"url,omitempty"`
+	ImageUrl string      `json:"image_url,omitempty"`
+	AltText  string      `json:"alt_text,omitempty"`
+}
@@ -0,0 +1,126 @@
+package slack
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/austinov/rocker-bot/config"
+
+	"golang.org/x/net/websocket"
+)
+
+const (
+	apiURL      = "https://api.slack.com/"
+	startRtmURL = "https://slack.com/api/rtm.start?token=%s"
+)
+
+// rtmTransport receives messages and sends replies through websocket
+// of Slack's Real Time Messaging API. Messages with attachments or blocks are
+// sent by chat.postMessage method, since RTM API doesn't support them.
+type rtmTransport struct {
+	cfg config.BotConfig
+	api *webAPI
+	mu  sync.RWMutex // it guards ws and ln, ws is replaced on reconnect
+	ws  *websocket.Conn
+	// endpoints, if not nil, serves endpoints of interactive messages and slash
+	// commands, it's started on listen address of interactivity configuration
+	endpoints *http.ServeMux
+	ln        net.Listener
+}
+
+func newRtmTransport(cfg config.BotConfig) *rtmTransport {
+	return &rtmTransport{
+		cfg: cfg,
+		api: newWebAPI(cfg.Token),
+	}
+}
+
+func (t *rtmTransport) connect() (string, error) {
+	resp, err := http.Get(fmt.Sprintf(startRtmURL, t.cfg.Token))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("Start RTM request failed with code %d", resp.StatusCode)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	var respRtm ResponseRtmStart
+	if err = json.Unmarshal(body, &respRtm); err != nil {
+		return "", err
+	}
+
+	if !respRtm.Ok {
+		return "", fmt.Errorf("Slack error: %s", respRtm.Error)
+	}
+
+	ws, err := websocket.Dial(respRtm.Url, "", apiURL)
+	if err != nil {
+		return "", err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.ws != nil {
+		t.ws.Close()
+	}
+	t.ws = ws
+
+	if t.endpoints != nil && t.ln == nil {
+		ln, err := net.Listen("tcp", t.cfg.Interactivity.Listen)
+		if err != nil {
+			return "", err
+		}
+		t.ln = ln
+		go http.Serve(ln, t.endpoints)
+	}
+
+	return respRtm.Self.Id, nil
+}
+
+func (t *rtmTransport) conn() *websocket.Conn {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.ws
+}
+
+func (t *rtmTransport) receive() (Message, error) {
+	var m Message
+	err := websocket.JSON.Receive(t.conn(), &m)
+	return m, err
+}
+
+// sequentially increased message counter
+var messageId uint64
+
+func (t *rtmTransport) send(msg Message) error {
+	if len(msg.Attachments) > 0 || len(msg.Blocks) > 0 {
+		return t.api.postMessage(msg)
+	}
+	msg.Id = atomic.AddUint64(&messageId, 1)
+	return websocket.JSON.Send(t.conn(), msg)
+}
+
+func (t *rtmTransport) close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.ln != nil {
+		t.ln.Close()
+		t.ln = nil
+	}
+	if t.ws == nil {
+		return nil
+	}
+	err := t.ws.Close()
+	t.ws = nil
+	return err
+}
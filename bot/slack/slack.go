@@ -0,0 +1,184 @@
+// Package slack connects the bot to Slack by RTM API, Events API or Socket Mode.
+package slack
+
+import (
+	"strings"
+
+	"github.com/austinov/rocker-bot/bot"
+	"github.com/austinov/rocker-bot/config"
+)
+
+// conn receives messages from Slack and sends replies to it.
+type conn interface {
+	// connect connects to Slack and returns bot's user id.
+	connect() (string, error)
+	// receive returns the next message, it blocks until message is received.
+	receive() (Message, error)
+	// send sends the message, it may be called from several go-routines.
+	send(msg Message) error
+	// close closes the connection, blocked receive returns error.
+	close() error
+}
+
+// Transport passes commands to the bot from Slack and renders its replies
+// by Slack's markup, attachments and blocks.
+type Transport struct {
+	cfg     config.BotConfig
+	c       conn
+	id      string
+	replier bot.Replier
+}
+
+func New(cfg config.BotConfig) *Transport {
+	t := &Transport{cfg: cfg}
+	switch cfg.Transport {
+	case "events":
+		t.c = newEventsTransport(cfg)
+	case "socket":
+		t.c = newSocketTransport(cfg)
+	default:
+		t.c = newRtmTransport(cfg)
+	}
+	if cfg.Interactivity.Enabled {
+		t.enableInteractivity()
+	}
+	if cfg.Slash.Enabled {
+		t.enableSlash()
+	}
+	return t
+}
+
+// SetReplier sets the bot which replies to interactive messages and slash commands.
+func (t *Transport) SetReplier(r bot.Replier) {
+	t.replier = r
+}
+
+// Connect connects to Slack. Bot's id is set on the first connect only,
+// it's the same for the token and it's read by handlers without locks.
+func (t *Transport) Connect() (string, error) {
+	id, err := t.c.connect()
+	if err != nil {
+		return "", err
+	}
+	if t.id == "" {
+		t.id = "<@" + id + ">"
+	}
+	return id, nil
+}
+
+// Receive returns the next message addressed to the bot,
+// other messages are skipped.
+func (t *Transport) Receive() (bot.Message, error) {
+	for {
+		m, err := t.c.receive()
+		if err != nil {
+			return bot.Message{}, err
+		}
+		if text, ok := t.command(m); ok {
+			return bot.Message{
+				Channel:  m.Channel,
+				User:     m.User,
+				Text:     text,
+				Id:       m.Ts,
+				ThreadId: m.ThreadTs,
+				Direct:   isDirect(m.Channel),
+			}, nil
+		}
+	}
+}
+
+// command returns text of command without mention of the bot if the message
+// is addressed to the bot. Commands in direct messages may be without mention.
+func (t *Transport) command(m Message) (string, bool) {
+	if m.Type != "message" || m.Subtype != "" || "<@"+m.User+">" == t.id {
+		// edited messages, messages of bots and the own ones are skipped
+		return "", false
+	}
+	if strings.HasPrefix(m.Text, t.id) {
+		return strings.TrimSpace(strings.TrimPrefix(m.Text, t.id)), true
+	}
+	if isDirect(m.Channel) {
+		return strings.TrimSpace(m.Text), true
+	}
+	return "", false
+}
+
+// isDirect returns true if the channel is a direct messages channel.
+func isDirect(channel string) bool {
+	return strings.HasPrefix(channel, "D")
+}
+
+func (t *Transport) Send(msg bot.Message) error {
+	text, attachments, blocks := t.render(msg)
+	return t.c.send(Message{
+		Type:        "message",
+		Channel:     msg.Channel,
+		Text:        text,
+		ThreadTs:    msg.ThreadId,
+		Attachments: attachments,
+		Blocks:      blocks,
+	})
+}
+
+func (t *Transport) Close() error {
+	return t.c.close()
+}
+
+// render returns text of the reply with attachments and blocks. Buttons to
+// load the next/previous events are attached if they are enabled, they
+// replace the footer of the text. Events are rendered as blocks if it's
+// configured, the text is a fallback.
+func (t *Transport) render(reply bot.Message) (string, []Attachment, []Block) {
+	p := reply.Page
+	if p == nil {
+		return reply.Text, nil, nil
+	}
+	text := reply.Text
+	var attachments []Attachment
+	var blocks []Block
+	if len(p.Events) > 0 && t.cfg.Format == blocksFormat {
+		blocks = formatBlocks(p.Header, p.Events)
+	}
+	if t.cfg.Interactivity.Enabled {
+		text = strings.TrimSuffix(text, p.Footer)
+		if p.Offset > 0 || p.More() {
+			attachments = pageButtons(p)
+		}
+	} else if blocks != nil && p.Footer != "" {
+		blocks = append(blocks, textBlock(p.Footer))
+	}
+	return text, attachments, blocks
+}
+
+// PageSize returns a maximum number of events in reply, it's less
+// for blocks since number of blocks in message is limited.
+func (t *Transport) PageSize() int {
+	if t.cfg.Format == blocksFormat {
+		return blocksLimit
+	}
+	return 0
+}
+
+func (t *Transport) Bold(text string) string {
+	return "*" + text + "*"
+}
+
+func (t *Transport) Italic(text string) string {
+	return "_" + text + "_"
+}
+
+func (t *Transport) Quote(line string) string {
+	return ">" + line
+}
+
+func (t *Transport) Mention(user string) string {
+	return "<@" + user + ">"
+}
+
+func (t *Transport) Command(text string) string {
+	return t.id + " " + text
+}
+
+func (t *Transport) DirectCommand(text string) string {
+	return "`" + text + "`"
+}
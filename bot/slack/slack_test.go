@@ -0,0 +1,102 @@
+package slack
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/austinov/rocker-bot/bot"
+	"github.com/austinov/rocker-bot/config"
+	"github.com/austinov/rocker-bot/store"
+	"github.com/austinov/rocker-bot/store/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeConn receives the messages and keeps the sent ones.
+type fakeConn struct {
+	messages []Message
+	sent     []Message
+}
+
+func (c *fakeConn) connect() (string, error) { return "bot", nil }
+
+func (c *fakeConn) receive() (Message, error) {
+	if len(c.messages) == 0 {
+		return Message{}, errors.New("connection is closed")
+	}
+	m := c.messages[0]
+	c.messages = c.messages[1:]
+	return m, nil
+}
+
+func (c *fakeConn) send(msg Message) error {
+	c.sent = append(c.sent, msg)
+	return nil
+}
+
+func (c *fakeConn) close() error { return nil }
+
+func TestReceive(t *testing.T) {
+	c := &fakeConn{messages: []Message{
+		// messages in channel without mention are skipped
+		{Type: "message", Channel: "C1", User: "U1", Text: "following"},
+		// own and edited messages are skipped
+		{Type: "message", Channel: "D1", User: "bot", Text: "events of Metallica"},
+		{Type: "message", Subtype: "message_changed", Channel: "D1", Text: "events of Metallica"},
+		{Type: "user_typing", Channel: "C1", User: "U1"},
+		{Type: "message", Channel: "C1", User: "U1", Text: "<@bot> following", Ts: "1.2", ThreadTs: "1.0"},
+		// command in direct message without mention
+		{Type: "message", Channel: "D1", User: "U1", Text: " events of Metallica", Ts: "1.3"},
+	}}
+	tr := &Transport{c: c}
+	id, err := tr.Connect()
+	assert.NoError(t, err)
+	assert.Equal(t, "bot", id)
+	assert.Equal(t, "<@bot>", tr.id)
+
+	m, err := tr.Receive()
+	assert.NoError(t, err)
+	assert.Equal(t, bot.Message{Channel: "C1", User: "U1", Text: "following", Id: "1.2", ThreadId: "1.0"}, m)
+	m, err = tr.Receive()
+	assert.NoError(t, err)
+	assert.Equal(t, bot.Message{Channel: "D1", User: "U1", Text: "events of Metallica", Id: "1.3", Direct: true}, m)
+	_, err = tr.Receive()
+	assert.Error(t, err)
+
+	assert.NoError(t, tr.Send(bot.Message{Channel: "C1", Text: "reply", ThreadId: "1.0"}))
+	assert.Equal(t, []Message{{Type: "message", Channel: "C1", Text: "reply", ThreadTs: "1.0"}}, c.sent)
+}
+
+func TestRenderBlocks(t *testing.T) {
+	dao := memory.New(config.DBConfig{Type: "memory"})
+	defer dao.Close()
+	dao.AddBandEvents([]store.Event{
+		{Band: "Metallica", Title: "Metallica", From: 1493856000, To: 1493856000, City: "Paris", Venue: "Stade de France",
+			Link: "http://example.com/event", Img: "http://example.com/poster.jpg"},
+	})
+	cfg := config.BotConfig{Token: "xxx", Format: "blocks"}
+	tr := New(cfg)
+	tr.id = "<@bot>"
+	bot.New(cfg, dao, tr)
+	assert.Equal(t, blocksLimit, tr.PageSize())
+
+	text, _, blocks := tr.render(tr.replier.Calendar(bot.Query{Command: "events", Band: "Metallica"}, 0))
+	// text is kept as fallback
+	assert.Equal(t,
+		"We known about the following events of *Metallica*:\n>4 May 2017, *Metallica* (Paris - _Stade de France_) - http://example.com/event\n",
+		text)
+	assert.Len(t, blocks, 4)
+	assert.Equal(t, "divider", blocks[1].Type)
+	section := blocks[2]
+	assert.Equal(t, "*Metallica*", section.Text.Text)
+	assert.Equal(t, []TextObject{
+		{Type: "mrkdwn", Text: "*Date*\n4 May 2017"},
+		{Type: "mrkdwn", Text: "*City*\nParis"},
+		{Type: "mrkdwn", Text: "*Venue*\nStade de France"},
+	}, section.Fields)
+	assert.Equal(t, "http://example.com/poster.jpg", section.Accessory.ImageUrl)
+	assert.Equal(t, "http://example.com/event", blocks[3].Elements[0].Url)
+
+	// no events, no blocks
+	_, _, blocks = tr.render(tr.replier.Calendar(bot.Query{Command: "events", Band: "Metallica", City: "London"}, 0))
+	assert.Nil(t, blocks)
+}
@@ -0,0 +1,118 @@
+package slack
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/austinov/rocker-bot/bot"
+)
+
+const (
+	defaultSlashPath = "/slack/commands"
+	// response types of slash command
+	ephemeralResponse = "ephemeral"
+	inChannelResponse = "in_channel"
+)
+
+// enableSlash makes the connection to pass slash commands to the bot.
+func (t *Transport) enableSlash() {
+	path := t.cfg.Slash.Path
+	if path == "" {
+		path = defaultSlashPath
+	}
+	if c, ok := t.c.(*socketTransport); ok {
+		c.command = t.slashCommand
+		return
+	}
+	t.handle(path, &slashHandler{
+		secret:  t.signingSecret(),
+		command: t.slashCommand,
+		respond: newWebAPI(t.cfg.Token).respond,
+	})
+}
+
+// slashCommand runs the slash command like the command in direct message
+// and returns the response. The response type may be chosen by the first
+// word of the command: public (in_channel) or private (ephemeral).
+func (t *Transport) slashCommand(cmd SlashCommand) CommandResponse {
+	responseType := t.cfg.Slash.ResponseType
+	if responseType == "" {
+		responseType = ephemeralResponse
+	}
+	text := strings.TrimSpace(cmd.Text)
+	fields := strings.Fields(text)
+	if len(fields) > 0 {
+		switch fields[0] {
+		case "public":
+			responseType = inChannelResponse
+			text = strings.TrimSpace(strings.TrimPrefix(text, fields[0]))
+		case "private":
+			responseType = ephemeralResponse
+			text = strings.TrimSpace(strings.TrimPrefix(text, fields[0]))
+		}
+	}
+	reply := t.replier.Reply(bot.Message{
+		Channel: cmd.ChannelId,
+		User:    cmd.UserId,
+		Text:    text,
+	})
+	text, attachments, blocks := t.render(reply)
+	return CommandResponse{
+		ResponseType: responseType,
+		Text:         text,
+		Attachments:  attachments,
+		Blocks:       blocks,
+	}
+}
+
+// slashHandler handles requests of Slack's slash command. The request is
+// acknowledged at once and the response is sent by response url later,
+// since Slack waits for the response 3 seconds only.
+type slashHandler struct {
+	secret  string
+	command func(cmd SlashCommand) CommandResponse
+	respond func(responseUrl string, resp interface{}) error
+}
+
+func (h *slashHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := verifySignature(h.secret, r.Header, body, time.Now()); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	cmd := SlashCommand{
+		Command:     form.Get("command"),
+		Text:        form.Get("text"),
+		UserId:      form.Get("user_id"),
+		ChannelId:   form.Get("channel_id"),
+		ResponseUrl: form.Get("response_url"),
+	}
+	if cmd.ResponseUrl == "" {
+		http.Error(w, "Response url is empty", http.StatusBadRequest)
+		return
+	}
+	go func() {
+		if err := h.respond(cmd.ResponseUrl, h.command(cmd)); err != nil {
+			fmt.Fprintf(os.Stderr, "response to slash command failed with %#v\n", err)
+		}
+	}()
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,82 @@
+package slack
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/austinov/rocker-bot/bot"
+	"github.com/austinov/rocker-bot/config"
+	"github.com/austinov/rocker-bot/store"
+	"github.com/austinov/rocker-bot/store/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlashCommand(t *testing.T) {
+	dao := memory.New(config.DBConfig{Type: "memory"})
+	defer dao.Close()
+	dao.AddBandEvents([]store.Event{
+		{Band: "Metallica", Title: "Metallica", From: 1493856000, To: 1493856000, City: "Paris"},
+	})
+	tr := New(config.BotConfig{Token: "xxx"})
+	tr.id = "<@bot>"
+	bot.New(config.BotConfig{Token: "xxx"}, dao, tr)
+
+	resp := tr.slashCommand(SlashCommand{Command: "/rocker", Text: "events in Paris", ChannelId: "C1", UserId: "U1"})
+	assert.Equal(t, ephemeralResponse, resp.ResponseType)
+	assert.Equal(t, "We known about the following events in _Paris_:\n>4 May 2017, *Metallica* (Paris) \n", resp.Text)
+
+	resp = tr.slashCommand(SlashCommand{Command: "/rocker", Text: "public events of Metallica", ChannelId: "C1", UserId: "U1"})
+	assert.Equal(t, inChannelResponse, resp.ResponseType)
+	assert.Contains(t, resp.Text, "events of *Metallica*")
+
+	tr.cfg.Slash.ResponseType = inChannelResponse
+	resp = tr.slashCommand(SlashCommand{Command: "/rocker", Text: "private following", ChannelId: "C1", UserId: "U1"})
+	assert.Equal(t, ephemeralResponse, resp.ResponseType)
+	resp = tr.slashCommand(SlashCommand{Command: "/rocker", Text: "help", ChannelId: "C1", UserId: "U1"})
+	assert.Equal(t, inChannelResponse, resp.ResponseType)
+	assert.Contains(t, resp.Text, "Please, use commands")
+}
+
+func TestSlashHandler(t *testing.T) {
+	type response struct {
+		url  string
+		resp interface{}
+	}
+	responses := make(chan response, 1)
+	h := &slashHandler{
+		secret: testSecret,
+		command: func(cmd SlashCommand) CommandResponse {
+			return CommandResponse{ResponseType: ephemeralResponse, Text: cmd.Text}
+		},
+		respond: func(responseUrl string, resp interface{}) error {
+			responses <- response{responseUrl, resp}
+			return nil
+		},
+	}
+	body := url.Values{
+		"command":      {"/rocker"},
+		"text":         {"events in Paris"},
+		"channel_id":   {"C1"},
+		"user_id":      {"U1"},
+		"response_url": {"http://slack/response"},
+	}.Encode()
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, signedRequest(body, time.Now()))
+	assert.Equal(t, http.StatusOK, w.Code)
+	select {
+	case r := <-responses:
+		assert.Equal(t, "http://slack/response", r.url)
+		assert.Equal(t, CommandResponse{ResponseType: ephemeralResponse, Text: "events in Paris"}, r.resp)
+	case <-time.After(time.Second):
+		t.Fatal("no response to slash command")
+	}
+
+	// request is not signed
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", defaultSlashPath, nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,192 @@
+package slack
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/austinov/rocker-bot/config"
+
+	"golang.org/x/net/websocket"
+)
+
+// socketTransport receives mentions and direct messages through websocket of
+// Slack's Socket Mode and sends replies by chat.postMessage method.
+// It doesn't need public HTTP endpoint unlike eventsTransport.
+type socketTransport struct {
+	*webAPI
+	app    *webAPI    // it calls methods with app-level token
+	mu     sync.Mutex // it guards ws and closed
+	ws     *websocket.Conn
+	closed bool
+	// interact, if not nil, handles callbacks of interactive messages
+	interact func(cb InteractiveCallback) (InteractiveResponse, error)
+	// command, if not nil, handles slash commands
+	command func(cmd SlashCommand) CommandResponse
+}
+
+func newSocketTransport(cfg config.BotConfig) *socketTransport {
+	return &socketTransport{
+		webAPI: newWebAPI(cfg.Token),
+		app:    newWebAPI(cfg.Socket.AppToken),
+	}
+}
+
+func (t *socketTransport) connect() (string, error) {
+	var auth ResponseAuthTest
+	if err := t.call("auth.test", nil, &auth); err != nil {
+		return "", err
+	}
+	if err := t.open(); err != nil {
+		return "", err
+	}
+	return auth.UserId, nil
+}
+
+// open opens new websocket connection, the previous one is closed.
+func (t *socketTransport) open() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closed = false
+	return t.openLocked()
+}
+
+func (t *socketTransport) openLocked() error {
+	t.dropLocked()
+	var resp ResponseConnectionsOpen
+	if err := t.app.call("apps.connections.open", nil, &resp); err != nil {
+		return err
+	}
+	ws, err := websocket.Dial(resp.Url, "", apiURL)
+	if err != nil {
+		return err
+	}
+	t.ws = ws
+	return nil
+}
+
+// conn returns websocket connection, it's reopened if it was dropped.
+func (t *socketTransport) conn() (*websocket.Conn, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return nil, errors.New("Socket Mode connection is closed")
+	}
+	if t.ws == nil {
+		if err := t.openLocked(); err != nil {
+			return nil, err
+		}
+	}
+	return t.ws, nil
+}
+
+// drop closes websocket connection, so it will be reopened on receive.
+func (t *socketTransport) drop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.dropLocked()
+}
+
+func (t *socketTransport) dropLocked() {
+	if t.ws != nil {
+		t.ws.Close()
+		t.ws = nil
+	}
+}
+
+// close closes websocket connection, it's not reopened until connect.
+func (t *socketTransport) close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closed = true
+	t.dropLocked()
+	return nil
+}
+
+// receive returns the next mention of the bot or direct message. Envelopes are acknowledged
+// and the connection is reopened when Slack asks to disconnect or it fails.
+// Interactive callbacks and slash commands are handled in go-routines.
+// It must not be called from several go-routines.
+// It returns error after close.
+func (t *socketTransport) receive() (Message, error) {
+	for {
+		ws, err := t.conn()
+		if err != nil {
+			return Message{}, err
+		}
+		var env SocketEnvelope
+		if err := websocket.JSON.Receive(ws, &env); err != nil {
+			t.drop()
+			return Message{}, err
+		}
+		if env.EnvelopeId != "" {
+			if err := websocket.JSON.Send(ws, SocketAck{EnvelopeId: env.EnvelopeId}); err != nil {
+				t.drop()
+				return Message{}, err
+			}
+		}
+		switch env.Type {
+		case "disconnect":
+			log.Printf("Socket Mode connection is closed by Slack (%s), reconnecting\n", env.Reason)
+			t.drop()
+		// the envelopes are acknowledged, so they are handled
+		// in background to not delay the next envelopes
+		case "interactive":
+			go t.handleInteractive(env.Payload)
+		case "slash_commands":
+			go t.handleSlashCommand(env.Payload)
+		case "events_api":
+			var cb EventCallback
+			if err := json.Unmarshal(env.Payload, &cb); err != nil {
+				return Message{}, fmt.Errorf("illegal events_api payload: %v", err)
+			}
+			if m, ok := callbackMessage(cb.Event); ok {
+				return m, nil
+			}
+		}
+	}
+}
+
+func (t *socketTransport) send(msg Message) error {
+	return t.postMessage(msg)
+}
+
+// handleInteractive handles callback of interactive message,
+// the original message is replaced by its response url.
+func (t *socketTransport) handleInteractive(payload []byte) {
+	if t.interact == nil {
+		return
+	}
+	var cb InteractiveCallback
+	if err := json.Unmarshal(payload, &cb); err != nil {
+		fmt.Fprintf(os.Stderr, "illegal interactive payload: %v\n", err)
+		return
+	}
+	resp, err := t.interact(cb)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "interactive callback failed with %#v\n", err)
+		return
+	}
+	if err := t.respond(cb.ResponseUrl, resp); err != nil {
+		fmt.Fprintf(os.Stderr, "response to interactive callback failed with %#v\n", err)
+	}
+}
+
+// handleSlashCommand handles slash command, the response is sent
+// by its response url.
+func (t *socketTransport) handleSlashCommand(payload []byte) {
+	if t.command == nil {
+		return
+	}
+	var cmd SlashCommand
+	if err := json.Unmarshal(payload, &cmd); err != nil {
+		fmt.Fprintf(os.Stderr, "illegal slash command payload: %v\n", err)
+		return
+	}
+	if err := t.respond(cmd.ResponseUrl, t.command(cmd)); err != nil {
+		fmt.Fprintf(os.Stderr, "response to slash command failed with %#v\n", err)
+	}
+}
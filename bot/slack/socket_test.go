@@ -0,0 +1,86 @@
+package slack
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/austinov/rocker-bot/config"
+	"github.com/stretchr/testify/assert"
+
+	"golang.org/x/net/websocket"
+)
+
+func mentionEnvelope(id, text string) SocketEnvelope {
+	return SocketEnvelope{
+		Type:       "events_api",
+		EnvelopeId: id,
+		Payload: []byte(`{"type":"event_callback","event":{"type":"app_mention",` +
+			`"user":"U1","channel":"C1","text":"` + text + `"}}`),
+	}
+}
+
+func TestSocketTransport(t *testing.T) {
+	var connections int32
+	acks := make(chan string, 10)
+
+	mux := http.NewServeMux()
+	slack := httptest.NewServer(mux)
+	defer slack.Close()
+
+	mux.HandleFunc("/auth.test", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer xoxb", r.Header.Get("Authorization"))
+		w.Write([]byte(`{"ok":true,"user_id":"UBOT"}`))
+	})
+	mux.HandleFunc("/apps.connections.open", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer xapp", r.Header.Get("Authorization"))
+		url := strings.Replace(slack.URL, "http://", "ws://", 1) + "/ws"
+		w.Write([]byte(`{"ok":true,"url":"` + url + `"}`))
+	})
+	mux.Handle("/ws", websocket.Handler(func(ws *websocket.Conn) {
+		n := atomic.AddInt32(&connections, 1)
+		websocket.JSON.Send(ws, SocketEnvelope{Type: "hello"})
+		if n == 1 {
+			websocket.JSON.Send(ws, mentionEnvelope("e1", "<@UBOT> events of Metallica"))
+			var ack SocketAck
+			websocket.JSON.Receive(ws, &ack)
+			acks <- ack.EnvelopeId
+			websocket.JSON.Send(ws, SocketEnvelope{Type: "disconnect", Reason: "refresh_requested"})
+		} else {
+			websocket.JSON.Send(ws, mentionEnvelope("e2", "<@UBOT> events in Paris"))
+			var ack SocketAck
+			websocket.JSON.Receive(ws, &ack)
+			acks <- ack.EnvelopeId
+		}
+		// wait until the client closes the connection
+		var env SocketEnvelope
+		websocket.JSON.Receive(ws, &env)
+	}))
+
+	tr := newSocketTransport(config.BotConfig{
+		Token:  "xoxb",
+		Socket: config.SocketConfig{AppToken: "xapp"},
+	})
+	tr.apiURL = slack.URL + "/"
+	tr.app.apiURL = slack.URL + "/"
+
+	id, err := tr.connect()
+	assert.NoError(t, err)
+	assert.Equal(t, "UBOT", id)
+
+	m, err := tr.receive()
+	assert.NoError(t, err)
+	assert.Equal(t, Message{Type: "message", Channel: "C1", User: "U1", Text: "<@UBOT> events of Metallica"}, m)
+	assert.Equal(t, "e1", <-acks)
+
+	// the connection is reopened after disconnect
+	m, err = tr.receive()
+	assert.NoError(t, err)
+	assert.Equal(t, Message{Type: "message", Channel: "C1", User: "U1", Text: "<@UBOT> events in Paris"}, m)
+	assert.Equal(t, "e2", <-acks)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&connections))
+
+	tr.close()
+}
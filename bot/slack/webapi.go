@@ -0,0 +1,80 @@
+package slack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+const webAPIURL = "https://slack.com/api/"
+
+// webAPI calls methods of Slack's Web API with the token.
+type webAPI struct {
+	token  string
+	apiURL string
+	client *http.Client
+}
+
+func newWebAPI(token string) *webAPI {
+	return &webAPI{
+		token:  token,
+		apiURL: webAPIURL,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// call calls the method of Slack's Web API with JSON arguments
+// and unmarshals the response into resp if it's not nil.
+func (a *webAPI) call(method string, args interface{}, resp interface{}) error {
+	var body []byte
+	if args != nil {
+		var err error
+		if body, err = json.Marshal(args); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest("POST", a.apiURL+method, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+a.token)
+	r, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	if r.StatusCode != 200 {
+		return fmt.Errorf("%s request failed with code %d", method, r.StatusCode)
+	}
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	var respAPI ResponseAPI
+	if err = json.Unmarshal(data, &respAPI); err != nil {
+		return err
+	}
+	if !respAPI.Ok {
+		return fmt.Errorf("Slack error: %s", respAPI.Error)
+	}
+	if resp != nil {
+		return json.Unmarshal(data, resp)
+	}
+	return nil
+}
+
+// postMessage sends the message by chat.postMessage method.
+func (a *webAPI) postMessage(msg Message) error {
+	return a.call("chat.postMessage", PostMessage{
+		Channel:     msg.Channel,
+		Text:        msg.Text,
+		ThreadTs:    msg.ThreadTs,
+		Attachments: msg.Attachments,
+		Blocks:      msg.Blocks,
+	}, nil)
+}
@@ -0,0 +1,49 @@
+package telegram
+
+import "encoding/json"
+
+// Response is a common response of Bot API methods.
+type Response struct {
+	Ok          bool            `json:"ok"`
+	Description string          `json:"description"`
+	Result      json.RawMessage `json:"result"`
+}
+
+type User struct {
+	Id       int64  `json:"id"`
+	IsBot    bool   `json:"is_bot"`
+	Username string `json:"username"`
+}
+
+type Chat struct {
+	Id   int64  `json:"id"`
+	Type string `json:"type"`
+}
+
+type Message struct {
+	MessageId int64  `json:"message_id"`
+	From      *User  `json:"from"`
+	Chat      Chat   `json:"chat"`
+	Text      string `json:"text"`
+}
+
+type Update struct {
+	UpdateId int64    `json:"update_id"`
+	Message  *Message `json:"message"`
+}
+
+// GetUpdates is a request of getUpdates method.
+type GetUpdates struct {
+	Offset         int64    `json:"offset"`
+	Timeout        int      `json:"timeout"`
+	AllowedUpdates []string `json:"allowed_updates"`
+}
+
+// SendMessage is a request of sendMessage method.
+type SendMessage struct {
+	ChatId                int64  `json:"chat_id"`
+	Text                  string `json:"text"`
+	ParseMode             string `json:"parse_mode,omitempty"`
+	ReplyToMessageId      int64  `json:"reply_to_message_id,omitempty"`
+	DisableWebPagePreview bool   `json:"disable_web_page_preview"`
+}
@@ -0,0 +1,257 @@
+// Package telegram connects the bot to Telegram by long polling of Bot API.
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/austinov/rocker-bot/bot"
+	"github.com/austinov/rocker-bot/config"
+)
+
+const (
+	defaultAPIURL  = "https://api.telegram.org/"
+	defaultTimeout = 30 * time.Second
+	// pageSize is a number of events in reply,
+	// Telegram accepts messages up to 4096 characters
+	pageSize = 20
+	markdown = "Markdown"
+)
+
+// Transport receives commands by getUpdates method of Telegram Bot API and
+// sends replies by sendMessage method. Commands are like "/events of Metallica",
+// they may be addressed to the bot by its username in groups, e.g.
+// "/events@rocker_bot of Metallica" or "@rocker_bot events of Metallica".
+// Commands in private chats may be without slash.
+type Transport struct {
+	cfg      config.TelegramConfig
+	client   *http.Client
+	username string // username of the bot, it's set on the first connect
+	offset   int64  // id of the next update
+	updates  []Update
+	mu       sync.Mutex // it guards ctx and cancel
+	ctx      context.Context
+	cancel   context.CancelFunc // it cancels pending requests on close
+}
+
+func New(cfg config.TelegramConfig) *Transport {
+	if cfg.APIURL == "" {
+		cfg.APIURL = defaultAPIURL
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	return &Transport{
+		cfg: cfg,
+		// long polling request is waited a bit more than its timeout
+		client: &http.Client{Timeout: cfg.Timeout + 10*time.Second},
+	}
+}
+
+// Connect checks the token and returns username of the bot.
+func (t *Transport) Connect() (string, error) {
+	t.mu.Lock()
+	if t.cancel == nil {
+		t.ctx, t.cancel = context.WithCancel(context.Background())
+	}
+	t.mu.Unlock()
+
+	var me User
+	if err := t.call("getMe", nil, &me); err != nil {
+		return "", err
+	}
+	if t.username == "" {
+		t.username = me.Username
+	}
+	return me.Username, nil
+}
+
+// Receive returns the next command to the bot, other messages are skipped.
+// It must not be called from several go-routines.
+func (t *Transport) Receive() (bot.Message, error) {
+	for {
+		if len(t.updates) == 0 {
+			var updates []Update
+			err := t.call("getUpdates", GetUpdates{
+				Offset:         t.offset,
+				Timeout:        int(t.cfg.Timeout / time.Second),
+				AllowedUpdates: []string{"message"},
+			}, &updates)
+			if err != nil {
+				return bot.Message{}, err
+			}
+			t.updates = updates
+			continue
+		}
+		u := t.updates[0]
+		t.updates = t.updates[1:]
+		t.offset = u.UpdateId + 1
+		if u.Message == nil {
+			continue
+		}
+		if text, ok := t.command(*u.Message); ok {
+			m := u.Message
+			return bot.Message{
+				Channel: strconv.FormatInt(m.Chat.Id, 10),
+				User:    strconv.FormatInt(m.From.Id, 10),
+				Text:    text,
+				Id:      strconv.FormatInt(m.MessageId, 10),
+				Direct:  m.Chat.Type == "private",
+			}, nil
+		}
+	}
+}
+
+// command returns text of command without slash and username of the bot
+// if the message is addressed to the bot.
+func (t *Transport) command(m Message) (string, bool) {
+	if m.From == nil || m.From.IsBot {
+		return "", false
+	}
+	text := strings.TrimSpace(m.Text)
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return "", false
+	}
+	mention := "@" + t.username
+	switch {
+	case strings.HasPrefix(fields[0], "/"):
+		name := fields[0][1:]
+		if i := strings.Index(name, "@"); i >= 0 {
+			if !strings.EqualFold(name[i:], mention) {
+				// command to another bot
+				return "", false
+			}
+			name = name[:i]
+		}
+		return strings.TrimSpace(name + " " + strings.TrimSpace(strings.TrimPrefix(text, fields[0]))), true
+	case strings.EqualFold(fields[0], mention):
+		return strings.TrimSpace(strings.TrimPrefix(text, fields[0])), true
+	case m.Chat.Type == "private":
+		return text, true
+	}
+	return "", false
+}
+
+// Send sends the reply as Markdown, it's sent as plain text
+// if Telegram can't parse its Markdown.
+func (t *Transport) Send(msg bot.Message) error {
+	chat, err := strconv.ParseInt(msg.Channel, 10, 64)
+	if err != nil {
+		return fmt.Errorf("Illegal chat id %s", msg.Channel)
+	}
+	send := SendMessage{
+		ChatId:                chat,
+		Text:                  msg.Text,
+		ParseMode:             markdown,
+		DisableWebPagePreview: true,
+	}
+	if msg.ThreadId != "" {
+		// Telegram has no threads, so the reply is posted as reply to the command
+		send.ReplyToMessageId, _ = strconv.ParseInt(msg.ThreadId, 10, 64)
+	}
+	err = t.call("sendMessage", send, nil)
+	if err != nil && strings.Contains(err.Error(), "can't parse entities") {
+		send.ParseMode = ""
+		err = t.call("sendMessage", send, nil)
+	}
+	return err
+}
+
+// Close cancels pending requests, blocked Receive returns error.
+func (t *Transport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.cancel != nil {
+		t.cancel()
+		t.cancel = nil
+	}
+	return nil
+}
+
+func (t *Transport) context() (context.Context, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.cancel == nil {
+		return nil, errors.New("Telegram connection is closed")
+	}
+	return t.ctx, nil
+}
+
+// call calls the method of Bot API with JSON arguments
+// and unmarshals its result into result if it's not nil.
+func (t *Transport) call(method string, args interface{}, result interface{}) error {
+	ctx, err := t.context()
+	if err != nil {
+		return err
+	}
+	var body []byte
+	if args != nil {
+		if body, err = json.Marshal(args); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest("POST", t.cfg.APIURL+"bot"+t.cfg.Token+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	r, err := t.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	var resp Response
+	if err = json.Unmarshal(data, &resp); err != nil {
+		return fmt.Errorf("%s request failed with code %d", method, r.StatusCode)
+	}
+	if !resp.Ok {
+		return fmt.Errorf("Telegram error: %s", resp.Description)
+	}
+	if result != nil {
+		return json.Unmarshal(resp.Result, result)
+	}
+	return nil
+}
+
+// PageSize returns a maximum number of events in reply.
+func (t *Transport) PageSize() int {
+	return pageSize
+}
+
+// Bold and Italic remove their markers from the text,
+// since Telegram's Markdown doesn't support nested entities.
+func (t *Transport) Bold(text string) string {
+	return "*" + strings.Replace(text, "*", "", -1) + "*"
+}
+
+func (t *Transport) Italic(text string) string {
+	return "_" + strings.Replace(text, "_", "", -1) + "_"
+}
+
+// Quote returns the line as item of list, Telegram's Markdown has no quotes.
+func (t *Transport) Quote(line string) string {
+	return "• " + line
+}
+
+func (t *Transport) Mention(user string) string {
+	return "[follower](tg://user?id=" + user + ")"
+}
+
+func (t *Transport) Command(text string) string {
+	return "/" + text
+}
@@ -0,0 +1,144 @@
+package telegram
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/austinov/rocker-bot/bot"
+	"github.com/austinov/rocker-bot/config"
+	"github.com/austinov/rocker-bot/store"
+	"github.com/austinov/rocker-bot/store/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubAPI is a stub of Telegram Bot API. It returns the updates once,
+// the next getUpdates requests are blocked until they are cancelled.
+type stubAPI struct {
+	updates []Update
+	sent    chan SendMessage
+}
+
+func (s *stubAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	var result interface{}
+	switch r.URL.Path {
+	case "/botxxx/getMe":
+		result = User{Id: 1, IsBot: true, Username: "rocker_bot"}
+	case "/botxxx/getUpdates":
+		if s.updates == nil {
+			<-r.Context().Done()
+			return
+		}
+		result, s.updates = s.updates, nil
+	case "/botxxx/sendMessage":
+		var m SendMessage
+		json.Unmarshal(body, &m)
+		if m.ParseMode != "" && strings.Contains(m.Text, "[") {
+			json.NewEncoder(w).Encode(Response{Description: "Bad Request: can't parse entities"})
+			return
+		}
+		s.sent <- m
+		result = true
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(Response{Description: "Not Found"})
+		return
+	}
+	data, _ := json.Marshal(result)
+	json.NewEncoder(w).Encode(Response{Ok: true, Result: data})
+}
+
+func message(id int64, chat Chat, text string) Update {
+	return Update{
+		UpdateId: id,
+		Message:  &Message{MessageId: id, From: &User{Id: 42}, Chat: chat, Text: text},
+	}
+}
+
+func TestTransport(t *testing.T) {
+	group := Chat{Id: -100, Type: "group"}
+	private := Chat{Id: 42, Type: "private"}
+	api := &stubAPI{
+		updates: []Update{
+			// messages without command, commands to another bots
+			// and messages of bots are skipped
+			message(1, group, "hello"),
+			message(2, group, "/events@other_bot of Metallica"),
+			{UpdateId: 3, Message: &Message{MessageId: 3, From: &User{Id: 2, IsBot: true}, Chat: group, Text: "/help"}},
+			{UpdateId: 4},
+			message(5, group, "/events@Rocker_Bot of Metallica"),
+			message(6, group, "@rocker_bot following"),
+			message(7, group, "/watching"),
+			message(8, private, "events in Paris"),
+		},
+		sent: make(chan SendMessage, 1),
+	}
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	tr := New(config.TelegramConfig{Token: "xxx", APIURL: server.URL + "/"})
+	username, err := tr.Connect()
+	assert.NoError(t, err)
+	assert.Equal(t, "rocker_bot", username)
+
+	for _, expected := range []bot.Message{
+		{Channel: "-100", User: "42", Text: "events of Metallica", Id: "5"},
+		{Channel: "-100", User: "42", Text: "following", Id: "6"},
+		{Channel: "-100", User: "42", Text: "watching", Id: "7"},
+		{Channel: "42", User: "42", Text: "events in Paris", Id: "8", Direct: true},
+	} {
+		m, err := tr.Receive()
+		assert.NoError(t, err)
+		assert.Equal(t, expected, m)
+	}
+	assert.Equal(t, int64(9), tr.offset)
+
+	// reply in thread is sent as reply to the command
+	assert.NoError(t, tr.Send(bot.Message{Channel: "-100", Text: "*Metallica*", ThreadId: "5"}))
+	assert.Equal(t, SendMessage{ChatId: -100, Text: "*Metallica*", ParseMode: "Markdown",
+		ReplyToMessageId: 5, DisableWebPagePreview: true}, <-api.sent)
+	// reply is sent as plain text if Markdown isn't parsed
+	assert.NoError(t, tr.Send(bot.Message{Channel: "42", Text: "[Metallica"}))
+	assert.Equal(t, SendMessage{ChatId: 42, Text: "[Metallica", DisableWebPagePreview: true}, <-api.sent)
+	assert.Error(t, tr.Send(bot.Message{Channel: "C1", Text: "text"}))
+
+	// close cancels long polling
+	received := make(chan error, 1)
+	go func() {
+		_, err := tr.Receive()
+		received <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	assert.NoError(t, tr.Close())
+	select {
+	case err := <-received:
+		assert.Error(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("receive is not cancelled by close")
+	}
+}
+
+func TestMarkup(t *testing.T) {
+	dao := memory.New(config.DBConfig{Type: "memory"})
+	defer dao.Close()
+	dao.AddBandEvents([]store.Event{
+		{Band: "Metallica", Title: "Metallica", From: 1493856000, To: 1493856000, City: "Paris", Venue: "Stade de France"},
+	})
+	tr := New(config.TelegramConfig{Token: "xxx"})
+	b := bot.New(config.BotConfig{}, dao, tr)
+
+	reply := b.Reply(bot.Message{Channel: "42", Text: "events of Metallica", Direct: true})
+	assert.Equal(t,
+		"We known about the following events of *Metallica*:\n• 4 May 2017, *Metallica* (Paris - _Stade de France_) \n",
+		reply.Text)
+	assert.Equal(t, pageSize, reply.Page.Limit)
+
+	reply = b.Reply(bot.Message{Channel: "42", Text: "following"})
+	assert.Equal(t, "You don't follow any band in this channel, you may use:\n• /follow Metallica", reply.Text)
+	assert.Equal(t, "[follower](tg://user?id=42)", tr.Mention("42"))
+}
@@ -0,0 +1,88 @@
+package bot
+
+import "github.com/austinov/rocker-bot/store"
+
+// Transport connects the bot to a chat, it receives commands to the bot
+// and sends replies. Texts of replies are formatted by its markup.
+type Transport interface {
+	Markup
+	// Connect connects to the chat and returns bot's user id.
+	Connect() (string, error)
+	// Receive returns the next command to the bot, it blocks until the command
+	// is received. Text of the message is the command without mention of the bot.
+	Receive() (Message, error)
+	// Send sends the reply, it may be called from several go-routines.
+	Send(msg Message) error
+	// Close closes the connection, blocked Receive returns error.
+	Close() error
+}
+
+// Markup formats texts of replies for the chat.
+type Markup interface {
+	Bold(text string) string
+	Italic(text string) string
+	// Quote formats the line as quote, e.g. an item of list.
+	Quote(line string) string
+	// Mention returns mention of the user to notify the user.
+	Mention(user string) string
+	// Command returns the command as the user would send it to the bot,
+	// e.g. "@rocker events of Metallica".
+	Command(text string) string
+}
+
+// Replier replies to commands, it's implemented by Bot.
+type Replier interface {
+	// Reply returns reply to the command.
+	Reply(msg Message) Message
+	// Calendar returns page of events of the query starting from offset.
+	Calendar(query Query, offset int) Message
+}
+
+// Interactive is implemented by transports which receive requests besides
+// messages, e.g. clicks on buttons or slash commands. The requests are
+// replied by the bot which is set as replier by New.
+type Interactive interface {
+	SetReplier(r Replier)
+}
+
+// DirectCommander is implemented by transports which receive commands
+// in direct messages without mention of the bot, the help mentions it then.
+type DirectCommander interface {
+	// DirectCommand returns the command as the user would send it
+	// in direct message to the bot, e.g. "events of Metallica".
+	DirectCommand(text string) string
+}
+
+// Pager is implemented by transports which limit number of events in reply,
+// e.g. by number of rich elements in message. Zero page size means
+// the default one.
+type Pager interface {
+	PageSize() int
+}
+
+// Message is a command received from the chat or a reply sent to it.
+type Message struct {
+	Channel  string
+	User     string
+	Text     string
+	Id       string // id of the received message in the chat
+	ThreadId string // id of the thread, the reply is posted into it
+	Direct   bool   // it's true for direct messages to the bot
+	Page     *Page  // page of events, it's set for replies with events
+}
+
+// Page is a page of events of reply. Transports may render it by rich
+// elements of the chat instead of the text of reply.
+type Page struct {
+	Query  Query
+	Offset int
+	Limit  int
+	Header string // formatted header of the events
+	Footer string // formatted command to load the next events, it ends text of reply
+	Events []store.Event
+}
+
+// More returns true if the query may have more events after the page.
+func (p *Page) More() bool {
+	return len(p.Events) >= p.Limit
+}
@@ -0,0 +1,163 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/austinov/rocker-bot/common"
+	"github.com/austinov/rocker-bot/store"
+)
+
+const (
+	// digestWeekday and digestHour are the day of week and the hour
+	// (local time) to post digests of watched cities
+	digestWeekday = time.Monday
+	digestHour    = 9
+	// digestLimit is a maximum number of events in digest
+	digestLimit = 42
+	// watchesInterval is an interval to check watches which digests are due
+	watchesInterval = time.Minute
+)
+
+// watchHandler saves watch of channel for the city. The city may be
+// misspelled, so the closest known name is used or names are suggested.
+func (b *Bot) watchHandler(channel string, query Query, now time.Time) string {
+	matches, err := b.dao.FindCities(query.City, maxSuggestions)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return "Sorry, we have some troubles"
+	}
+	name, names := closestName(matches)
+	if name == "" && len(names) > 0 {
+		out := fmt.Sprintf("We don't know city %s. Did you mean:\n", b.t.Italic(query.City))
+		for _, n := range names {
+			out += b.t.Quote(b.t.Command("watch "+n)) + "\n"
+		}
+		return out
+	}
+	if name == "" {
+		// city may have no events yet
+		name = query.City
+	}
+	err = b.dao.AddWatch(store.Watch{
+		Channel: channel,
+		City:    name,
+		NextRun: nextDigest(now).Unix(),
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return "Sorry, we have some troubles"
+	}
+	return fmt.Sprintf("The digest of events in %s will be posted in this channel every %s.", b.t.Italic(name), digestWeekday)
+}
+
+// unwatchHandler removes watch of channel for the city.
+func (b *Bot) unwatchHandler(channel string, query Query) string {
+	if err := b.dao.RemoveWatch(channel, query.City); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return "Sorry, we have some troubles"
+	}
+	return fmt.Sprintf("The digest of events in %s won't be posted in this channel anymore.", b.t.Italic(query.City))
+}
+
+// watchingHandler returns a reply containing cities watched in channel.
+func (b *Bot) watchingHandler(channel string) string {
+	watches, err := b.dao.GetWatches(channel)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return "Sorry, we have some troubles"
+	}
+	if len(watches) == 0 {
+		return "No cities are watched in this channel, you may use:\n" + b.t.Quote(b.t.Command("watch Helsinki"))
+	}
+	buffer := bytes.NewBufferString("The following cities are watched in this channel:\n")
+	for _, w := range watches {
+		next := time.Unix(w.NextRun, 0).Format("2 Jan 2006 15:04")
+		buffer.WriteString(b.t.Quote(fmt.Sprintf("%s (next digest at %s)", w.City, next)) + "\n")
+	}
+	return buffer.String()
+}
+
+// runWatches posts digests of watched cities when they are due.
+// Watches are kept in db, so digests missed while the bot was down
+// are posted after start.
+func (b *Bot) runWatches(ctx context.Context, ignoreIn <-chan interface{}, ignoreOut chan<- interface{}) {
+	ticker := time.NewTicker(watchesInterval)
+	defer ticker.Stop()
+
+	b.postDigests(time.Now())
+	for {
+		select {
+		case now := <-ticker.C:
+			b.postDigests(now)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// postDigests posts digests of watches which are due at now
+// and schedules the next ones.
+func (b *Bot) postDigests(now time.Time) {
+	watches, err := b.dao.GetDueWatches(now.Unix())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "get due watches failed with %#v\n", err)
+		return
+	}
+	for _, w := range watches {
+		text, err := b.digest(w.City, now)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "digest of %s failed with %#v\n", w.City, err)
+			continue
+		}
+		if err := b.send(Message{Channel: w.Channel, Text: text}); err != nil {
+			fmt.Fprintf(os.Stderr, "send digest failed with %#v\n", err)
+			continue
+		}
+		w.NextRun = nextDigest(now).Unix()
+		if err := b.dao.AddWatch(w); err != nil {
+			fmt.Fprintf(os.Stderr, "update watch failed with %#v\n", err)
+		}
+	}
+}
+
+// digest returns text of digest with events in the city for the week since now.
+func (b *Bot) digest(city string, now time.Time) (string, error) {
+	from := common.BeginOfDate(now)
+	to := from.AddDate(0, 0, 7).Add(-time.Second)
+	events, err := b.dao.GetEvents(store.Filter{
+		City: city,
+		From: from.Unix(),
+		To:   to.Unix(),
+	}, 0, digestLimit)
+	if err != nil {
+		return "", err
+	}
+	week := fmt.Sprintf("%s - %s", from.Format("2 Jan"), to.Format("2 Jan"))
+	l := len(events)
+	if l == 0 {
+		return fmt.Sprintf("We have no info about events in %s for the week %s.", b.t.Italic(city), week), nil
+	}
+	out := fmt.Sprintf("Events in %s for the week %s:\n", b.t.Italic(city), week)
+	for _, event := range events {
+		out += b.formatEvent(event)
+	}
+	if l >= digestLimit {
+		q := Query{Command: "events", City: city, From: events[l-1].From, To: to.Unix()}
+		out += "To load next portion of events you may use:\n" + b.t.Quote(b.t.Command(formatCommand(q)))
+	}
+	return out, nil
+}
+
+// nextDigest returns time of the next digest after t.
+func nextDigest(t time.Time) time.Time {
+	d := common.BeginOfDate(t).Add(digestHour * time.Hour)
+	d = d.AddDate(0, 0, (int(digestWeekday)-int(d.Weekday())+7)%7)
+	if !d.After(t) {
+		d = d.AddDate(0, 0, 7)
+	}
+	return d
+}
@@ -73,3 +73,13 @@ func (f *Fuse) Process(kind string, err error) {
 		}
 	}
 }
+
+// Reset resets counters of errors of all kinds.
+func (f *Fuse) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for kind, state := range f.states {
+		state.errors = 0
+		f.states[kind] = state
+	}
+}
@@ -22,4 +22,9 @@ func TestFuse(t *testing.T) {
 	assert.False(t, triggered)
 	fuse.Process("APP", fmt.Errorf("APP error"))
 	assert.True(t, triggered)
+
+	triggered = false
+	fuse.Reset()
+	fuse.Process("APP", fmt.Errorf("APP error"))
+	assert.False(t, triggered)
 }
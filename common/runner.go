@@ -1,11 +1,19 @@
 package common
 
-import "sync"
+import (
+	"context"
+	"sync"
+)
 
-type Worker func(in <-chan interface{}, out chan<- interface{})
+// Worker reads values from in channel and writes results into out channel.
+// It must return when in channel is closed or, if it's a producer
+// without in channel, when ctx is done.
+type Worker func(ctx context.Context, in <-chan interface{}, out chan<- interface{})
 
 // runWorkers runs several workers passing them in/out channels.
-func RunWorkers(wg *sync.WaitGroup,
+// The out channel is closed when all workers returned, so the next
+// workers drain it and return too.
+func RunWorkers(ctx context.Context, wg *sync.WaitGroup,
 	in <-chan interface{}, out chan<- interface{},
 	numWorkers int, w Worker) {
 	go func() {
@@ -21,7 +29,7 @@ func RunWorkers(wg *sync.WaitGroup,
 			wg_.Add(1)
 			go func() {
 				defer wg_.Done()
-				w(in, out)
+				w(ctx, in, out)
 			}()
 		}
 		wg_.Wait()
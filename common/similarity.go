@@ -0,0 +1,70 @@
+package common
+
+import (
+	"strings"
+	"unicode"
+)
+
+// folding maps letters with diacritics to their base letters.
+var folding = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ą': "a", 'æ': "ae",
+	'ç': "c", 'ć': "c", 'č': "c",
+	'ď': "d", 'đ': "d",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ę': "e", 'ě': "e",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i",
+	'ł': "l",
+	'ñ': "n", 'ń': "n", 'ň': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ő': "o", 'œ': "oe",
+	'ř': "r",
+	'ś': "s", 'š': "s", 'ß': "ss",
+	'ť': "t",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ů': "u", 'ű': "u",
+	'ý': "y", 'ÿ': "y",
+	'ź': "z", 'ż': "z", 'ž': "z",
+	'ё': "е",
+}
+
+// Fold returns the text in lower case with letters without diacritics.
+func Fold(text string) string {
+	buf := make([]rune, 0, len(text))
+	for _, r := range strings.ToLower(text) {
+		if f, ok := folding[r]; ok {
+			buf = append(buf, []rune(f)...)
+		} else {
+			buf = append(buf, r)
+		}
+	}
+	return string(buf)
+}
+
+// Similarity returns how similar two texts are as a number from 0 to 1.
+// It counts trigrams the same way as pg_trgm does (words are padded with
+// two spaces at the begin and one space at the end), but folds diacritics
+// before, so Motörhead is the same as Motorhead.
+func Similarity(a, b string) float64 {
+	ta, tb := trigrams(Fold(a)), trigrams(Fold(b))
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	common := 0
+	for t := range ta {
+		if _, ok := tb[t]; ok {
+			common++
+		}
+	}
+	return float64(common) / float64(len(ta)+len(tb)-common)
+}
+
+func trigrams(text string) map[string]struct{} {
+	result := make(map[string]struct{})
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, w := range words {
+		rs := []rune("  " + w + " ")
+		for i := 0; i+3 <= len(rs); i++ {
+			result[string(rs[i:i+3])] = struct{}{}
+		}
+	}
+	return result
+}
@@ -0,0 +1,21 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFold(t *testing.T) {
+	assert.Equal(t, "motorhead", Fold("Motörhead"))
+	assert.Equal(t, "москва", Fold("Москва"))
+}
+
+func TestSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, Similarity("Motorhead", "Motörhead"))
+	assert.Equal(t, 1.0, Similarity("AC/DC", "ac dc"))
+	assert.Equal(t, 0.0, Similarity("Metallica", ""))
+	assert.Equal(t, 0.0, Similarity("Metallica", "Slayer"))
+	assert.InDelta(t, 0.727, Similarity("metalica", "Metallica"), 0.001)
+	assert.InDelta(t, 0.631, Similarity("St. Petersburg", "Saint Petersburg"), 0.001)
+}
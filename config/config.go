@@ -25,11 +25,102 @@ type (
 		Token       string `yaml:"token"`
 		NumHandlers int    `yaml:"num-handlers"`
 		NumSenders  int    `yaml:"num-senders"`
+		// ReplyPolicy is resend (default) or drop failed replies
+		ReplyPolicy string `yaml:"reply-policy"`
+		// LongReplyLines is a number of lines of reply to post it in thread
+		// of the request, replies aren't moved into threads if it's 0
+		LongReplyLines int `yaml:"long-reply-lines"`
+		// Format of events is text (default) or blocks (Block Kit with images)
+		Format string `yaml:"format"`
+		// ICalURL is a public url of REST API to make links to iCal feeds,
+		// ical command isn't available if it's empty
+		ICalURL string `yaml:"ical-url"`
+		// Transport is rtm (default), events or socket for Slack, telegram, discord or mattermost
+		Transport     string              `yaml:"transport"`
+		Events        EventsConfig        `yaml:"events"`
+		Socket        SocketConfig        `yaml:"socket"`
+		Interactivity InteractivityConfig `yaml:"interactivity"`
+		Slash         SlashConfig         `yaml:"slash"`
+		Telegram      TelegramConfig      `yaml:"telegram"`
+		Discord       DiscordConfig       `yaml:"discord"`
+		Mattermost    MattermostConfig    `yaml:"mattermost"`
+	}
+
+	// TelegramConfig is a configuration of Telegram Bot API connection.
+	TelegramConfig struct {
+		Token string `yaml:"token"`
+		// APIURL is a base url of Bot API, default is https://api.telegram.org/
+		APIURL string `yaml:"api-url"`
+		// Timeout of long polling of updates, default is 30s
+		Timeout time.Duration `yaml:"timeout"`
+	}
+
+	// EventsConfig is a configuration of Slack's Events API endpoint.
+	EventsConfig struct {
+		Listen        string `yaml:"listen"`
+		Path          string `yaml:"path"`
+		SigningSecret string `yaml:"signing-secret"`
+	}
+
+	// SocketConfig is a configuration of Slack's Socket Mode connection.
+	SocketConfig struct {
+		AppToken string `yaml:"app-token"`
+	}
+
+	// SlashConfig is a configuration of endpoint of /rocker slash command.
+	// It's served like endpoint of interactive messages.
+	SlashConfig struct {
+		Enabled bool   `yaml:"enabled"`
+		Path    string `yaml:"path"`
+		// ResponseType is ephemeral (default) or in_channel
+		ResponseType string `yaml:"response-type"`
+	}
+
+	// InteractivityConfig is a configuration of endpoint of interactive messages.
+	// Listen and SigningSecret are used by rtm transport only, events transport
+	// serves the endpoint with Events API and socket one receives callbacks by socket.
+	InteractivityConfig struct {
+		Enabled       bool   `yaml:"enabled"`
+		Listen        string `yaml:"listen"`
+		Path          string `yaml:"path"`
+		SigningSecret string `yaml:"signing-secret"`
+	}
+
+	// DiscordConfig is a configuration of Discord gateway connection.
+	DiscordConfig struct {
+		Token string `yaml:"token"`
+		// GatewayURL is a url of gateway websocket, default is wss://gateway.discord.gg/?v=10&encoding=json
+		GatewayURL string `yaml:"gateway-url"`
+		// APIURL is a base url of REST API, default is https://discord.com/api/v10/
+		APIURL string `yaml:"api-url"`
+		// Guilds are configurations of guilds by their ids,
+		// other guilds use the default configuration
+		Guilds map[string]DiscordGuildConfig `yaml:"guilds"`
+	}
+
+	// DiscordGuildConfig is a configuration of the bot in Discord guild.
+	DiscordGuildConfig struct {
+		// Format of events is embeds (default, with posters) or text
+		Format string `yaml:"format"`
+		// Slash registers /rocker command in the guild
+		Slash bool `yaml:"slash"`
+		// Channels are ids of channels where the bot answers mentions,
+		// it answers in all channels if it's empty
+		Channels []string `yaml:"channels"`
+	}
+
+	// MattermostConfig is a configuration of Mattermost connection.
+	MattermostConfig struct {
+		// URL is a url of Mattermost server, e.g. https://mattermost.example.com
+		URL string `yaml:"url"`
+		// Token is a token of bot account or personal access token
+		Token string `yaml:"token"`
 	}
 
 	DBConfig struct {
 		Type             string `yaml:"type"`
 		ConnectionString string `yaml:"connection-string"`
+		Aliases          string `yaml:"aliases"`
 	}
 
 	CMetalConfig struct {
@@ -39,16 +130,26 @@ type (
 		NumSavers  int           `yaml:"num-savers"`
 	}
 
+	// APIConfig is a configuration of REST API of the events calendar.
+	// The API serves iCal feeds of bands and cities too.
+	APIConfig struct {
+		Enabled bool   `yaml:"enabled"`
+		Listen  string `yaml:"listen"`
+	}
+
 	Config struct {
 		Bot    BotConfig    `yaml:"bot"`
 		DB     DBConfig     `yaml:"db"`
 		CMetal CMetalConfig `yaml:"cmetal"`
+		API    APIConfig    `yaml:"api"`
+		// ShutdownTimeout is a maximum time to stop the application gracefully
+		ShutdownTimeout time.Duration `yaml:"shutdown-timeout"`
 	}
 )
 
+// init registers flags of the configuration, they are parsed in main.
 func init() {
 	flag.StringVar(&cfgPath, "config", defaultCfgPath, "application's configuration file")
-	flag.Parse()
 }
 
 var once sync.Once
@@ -64,11 +165,32 @@ func GetConfig() Config {
 		if err != nil {
 			log.Fatal(err)
 		}
-		log.Printf("Parsed configuration: %#v\n", cfg)
+		log.Printf("Parsed configuration: %#v\n", cfg.redacted())
 	})
 	return cfg
 }
 
+// redacted returns copy of the configuration to print it, tokens, secrets
+// and connection string of PostgreSQL (it may contain password) are hidden.
+func (c Config) redacted() Config {
+	hide := func(s *string) {
+		if *s != "" {
+			*s = "***"
+		}
+	}
+	hide(&c.Bot.Token)
+	hide(&c.Bot.Events.SigningSecret)
+	hide(&c.Bot.Socket.AppToken)
+	hide(&c.Bot.Interactivity.SigningSecret)
+	hide(&c.Bot.Telegram.Token)
+	hide(&c.Bot.Discord.Token)
+	hide(&c.Bot.Mattermost.Token)
+	if c.DB.Type == "pg" {
+		hide(&c.DB.ConnectionString)
+	}
+	return c
+}
+
 func (c Config) Verify() error {
 	if err := c.Bot.Verify(); err != nil {
 		return err
@@ -79,12 +201,66 @@ func (c Config) Verify() error {
 	if err := c.CMetal.Verify(); err != nil {
 		return err
 	}
+	if c.API.Enabled {
+		if err := c.API.Verify(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 func (c BotConfig) Verify() error {
-	if c.Token == "" {
-		return errors.New("Bot token is empty")
+	switch c.ReplyPolicy {
+	case "", "resend", "drop":
+	default:
+		return errors.New("Unknown bot reply policy " + c.ReplyPolicy)
+	}
+	switch c.Format {
+	case "", "text", "blocks":
+	default:
+		return errors.New("Unknown bot format " + c.Format)
+	}
+	switch c.Slash.ResponseType {
+	case "", "ephemeral", "in_channel":
+	default:
+		return errors.New("Unknown slash command response type " + c.Slash.ResponseType)
+	}
+	switch c.Transport {
+	case "telegram":
+		return c.Telegram.Verify()
+	case "discord":
+		return c.Discord.Verify()
+	case "mattermost":
+		return c.Mattermost.Verify()
+	case "", "rtm", "events", "socket":
+		if c.Token == "" {
+			return errors.New("Bot token is empty")
+		}
+	default:
+		return errors.New("Unknown bot transport " + c.Transport)
+	}
+	switch c.Transport {
+	case "", "rtm":
+		if c.Interactivity.Enabled || c.Slash.Enabled {
+			return c.Interactivity.Verify()
+		}
+	case "events":
+		if c.Interactivity.Enabled && c.Interactivity.Path == c.Events.Path && c.Events.Path != "" {
+			return errors.New("Interactivity path is the same as Events API path")
+		}
+		return c.Events.Verify()
+	case "socket":
+		return c.Socket.Verify()
+	}
+	return nil
+}
+
+func (c EventsConfig) Verify() error {
+	if c.Listen == "" {
+		return errors.New("Events API listen address is empty")
+	}
+	if c.SigningSecret == "" {
+		return errors.New("Events API signing secret is empty")
 	}
 	return nil
 }
@@ -102,3 +278,58 @@ func (c CMetalConfig) Verify() error {
 	}
 	return nil
 }
+
+func (c APIConfig) Verify() error {
+	if c.Listen == "" {
+		return errors.New("API listen address is empty")
+	}
+	return nil
+}
+
+func (c InteractivityConfig) Verify() error {
+	if c.Listen == "" {
+		return errors.New("Interactivity listen address is empty")
+	}
+	if c.SigningSecret == "" {
+		return errors.New("Interactivity signing secret is empty")
+	}
+	return nil
+}
+
+func (c SocketConfig) Verify() error {
+	if c.AppToken == "" {
+		return errors.New("Socket Mode app-level token is empty")
+	}
+	return nil
+}
+
+func (c TelegramConfig) Verify() error {
+	if c.Token == "" {
+		return errors.New("Telegram bot token is empty")
+	}
+	return nil
+}
+
+func (c DiscordConfig) Verify() error {
+	if c.Token == "" {
+		return errors.New("Discord bot token is empty")
+	}
+	for id, g := range c.Guilds {
+		switch g.Format {
+		case "", "embeds", "text":
+		default:
+			return errors.New("Unknown format " + g.Format + " of Discord guild " + id)
+		}
+	}
+	return nil
+}
+
+func (c MattermostConfig) Verify() error {
+	if c.URL == "" {
+		return errors.New("Mattermost url is empty")
+	}
+	if c.Token == "" {
+		return errors.New("Mattermost token is empty")
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package cmetal
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -20,6 +21,11 @@ type cmetalBand struct {
 	Name string
 }
 
+type cmetalVenue struct {
+	Name    string
+	Address string
+}
+
 type CMetalLoader struct {
 	cfg        config.CMetalConfig
 	dao        store.Dao
@@ -27,14 +33,23 @@ type CMetalLoader struct {
 	fuse       *common.Fuse
 	bands      chan cmetalBand
 	events     chan store.Event
-	done       chan struct{}
+	mu         sync.Mutex
+	cancel     context.CancelFunc // it stops the running loader
+	err        error              // error which stopped the loader
+	venuesMu   sync.Mutex
+	venues     map[string]cmetalVenue // event's url -> venue, it's cleared on each loading
+	listener   loader.Listener
+	newMu      sync.Mutex
+	newEvents  []store.Event // events added by the current loading
 }
 
-func New(cfg config.CMetalConfig, dao store.Dao) loader.Loader {
+// New returns loader of events from concerts-metal.com.
+// The listener, if not nil, is called with new events after each loading.
+func New(cfg config.CMetalConfig, dao store.Dao, listener loader.Listener) loader.Loader {
 	loader := &CMetalLoader{
 		cfg:        cfg,
 		dao:        dao,
-		done:       make(chan struct{}),
+		listener:   listener,
 		httpclient: common.NewHTTPClient(30 * time.Second),
 	}
 	fuseTriggers := make([]common.FuseTrigger, 0)
@@ -54,61 +69,101 @@ func New(cfg config.CMetalConfig, dao store.Dao) loader.Loader {
 	return loader
 }
 
-func (l *CMetalLoader) Start() error {
-	if err := l.do(); err != nil {
+// Start runs the loader until ctx is done or the loader is stopped.
+// It returns error if the loader is stopped by the fuse.
+func (l *CMetalLoader) Start(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	l.mu.Lock()
+	l.cancel = cancel
+	l.err = nil
+	l.mu.Unlock()
+	l.fuse.Reset()
+
+	if err := l.do(ctx); err != nil {
 		return err
 	}
 	if l.cfg.Frequency == 0 {
-		return nil
+		return l.failure()
 	}
 
 	ticker := time.NewTicker(l.cfg.Frequency)
+	defer ticker.Stop()
 	for {
 		select {
 		case <-ticker.C:
-			if err := l.do(); err != nil {
+			if err := l.do(ctx); err != nil {
 				return err
 			}
-		case _, ok := <-l.done:
-			if !ok {
-				return nil
-			}
+		case <-ctx.Done():
+			return l.failure()
 		}
 	}
 }
 
+// failure returns error which stopped the loader or nil.
+func (l *CMetalLoader) failure() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
 func (l *CMetalLoader) Stop() {
 	log.Println("Loader stopping")
-	close(l.done)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.cancel != nil {
+		l.cancel()
+	}
 }
 
 func (l *CMetalLoader) fuseHandler(kind string, err error) {
 	fmt.Fprintf(os.Stderr, "loader failed due %s error: %#v\n", kind, err)
+	l.mu.Lock()
+	if l.err == nil {
+		l.err = fmt.Errorf("loader failed due %s error: %v", kind, err)
+	}
+	l.mu.Unlock()
 	l.Stop()
 }
 
-func (l *CMetalLoader) do() error {
+// do loads events once. When ctx is done, bands which are being loaded
+// are saved and the rest ones are skipped.
+func (l *CMetalLoader) do(ctx context.Context) error {
+	l.venuesMu.Lock()
+	l.venues = make(map[string]cmetalVenue)
+	l.venuesMu.Unlock()
+	l.newMu.Lock()
+	l.newEvents = make([]store.Event, 0)
+	l.newMu.Unlock()
+
 	var wg sync.WaitGroup
 
 	wg.Add(1)
 	bands := make(chan interface{}, l.cfg.NumLoaders)
-	common.RunWorkers(&wg, nil, bands, 1, l.loadBands)
+	common.RunWorkers(ctx, &wg, nil, bands, 1, l.loadBands)
 
 	wg.Add(1)
 	bandEvents := make(chan interface{}, l.cfg.NumSavers)
-	common.RunWorkers(&wg, bands, bandEvents, l.cfg.NumLoaders, l.loadBandEvents)
+	common.RunWorkers(ctx, &wg, bands, bandEvents, l.cfg.NumLoaders, l.loadBandEvents)
 
 	wg.Add(1)
-	common.RunWorkers(&wg, bandEvents, nil, l.cfg.NumSavers, l.saveBandEvents)
+	common.RunWorkers(ctx, &wg, bandEvents, nil, l.cfg.NumSavers, l.saveBandEvents)
 
 	wg.Wait()
 
+	l.newMu.Lock()
+	newEvents := l.newEvents
+	l.newMu.Unlock()
+	if l.listener != nil && len(newEvents) > 0 {
+		l.listener(ctx, newEvents)
+	}
 	return nil
 }
 
 // loadBands loads bands without events and put them into outBands channel
 // to load the events these bands.
-func (l *CMetalLoader) loadBands(ignore <-chan interface{}, outBands chan<- interface{}) {
+func (l *CMetalLoader) loadBands(ctx context.Context, ignore <-chan interface{}, outBands chan<- interface{}) {
 	doc := l.loadHTMLDocument(l.cfg.BaseURL + "search.php")
 	if doc == nil {
 		return
@@ -121,10 +176,8 @@ func (l *CMetalLoader) loadBands(ignore <-chan interface{}, outBands chan<- inte
 				name := ss.Text()
 				if id != "" && name != "" {
 					select {
-					case _, ok := <-l.done:
-						if !ok {
-							return
-						}
+					case <-ctx.Done():
+						return
 					default:
 						if name != "band" { // reserved word
 							outBands <- cmetalBand{
@@ -141,8 +194,12 @@ func (l *CMetalLoader) loadBands(ignore <-chan interface{}, outBands chan<- inte
 
 // loadBandEvents loads events for band from inBands channel and
 // put them into outEvents channel to save into DB.
-func (l *CMetalLoader) loadBandEvents(inBands <-chan interface{}, outEvents chan<- interface{}) {
+func (l *CMetalLoader) loadBandEvents(ctx context.Context, inBands <-chan interface{}, outEvents chan<- interface{}) {
 	for e := range inBands {
+		if ctx.Err() != nil {
+			// drain the bands
+			continue
+		}
 		band, ok := e.(cmetalBand)
 		if !ok {
 			l.fuse.Process("APP", fmt.Errorf("Illegal type of argument, expected dao.Band"))
@@ -188,12 +245,30 @@ func (l *CMetalLoader) loadBandEvents(inBands <-chan interface{}, outEvents chan
 				})
 			}
 		})
+
+		/* Genres */
+		genres := l.getBandGenres(doc)
+		for i := range events {
+			events[i].Genres = genres
+		}
 		outEvents <- events
 	}
 }
 
+// getBandGenres returns band's genres from the band's page.
+func (l *CMetalLoader) getBandGenres(doc *goquery.Document) []string {
+	var genres []string
+	doc.Find("td, div, span").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		if genres = parseGenres(s.Text()); len(genres) > 0 {
+			return false
+		}
+		return true
+	})
+	return genres
+}
+
 // saveBandEvents saves band's events from inEvents channel into DB.
-func (l *CMetalLoader) saveBandEvents(inEvents <-chan interface{}, ignore chan<- interface{}) {
+func (l *CMetalLoader) saveBandEvents(ctx context.Context, inEvents <-chan interface{}, ignore chan<- interface{}) {
 	for e := range inEvents {
 		events, ok := e.([]store.Event)
 		if !ok {
@@ -201,8 +276,13 @@ func (l *CMetalLoader) saveBandEvents(inEvents <-chan interface{}, ignore chan<-
 			continue
 		}
 		if len(events) > 0 {
-			if err := l.dao.AddBandEvents(events); err != nil {
+			newEvents, err := l.dao.AddBandEvents(events)
+			if err != nil {
 				fmt.Fprintf(os.Stderr, "save band's (%s) events failed with %#v\n", events[0].Band, err)
+			} else if len(newEvents) > 0 {
+				l.newMu.Lock()
+				l.newEvents = append(l.newEvents, newEvents...)
+				l.newMu.Unlock()
 			}
 			log.Printf("saveBandEvents: %#v\n", events[0].Band)
 		}
@@ -229,31 +309,37 @@ func (l *CMetalLoader) getNextEvents(band cmetalBand, s *goquery.Selection) ([]s
 						eventHref = l.buildURL(eventHref)
 						eventImg := ""
 						if linkImg := eventLink.Find("img"); linkImg != nil {
-							eventImg, _ = linkImg.Attr("src")
+							src, _ := linkImg.Attr("src")
+							eventImg = l.imageURL(src)
 						}
 						eventDate := eventDetail[1]
 						eventCity := clearCity(eventDetail[2])
+						eventCountry := parseCountry(eventDetail[2])
 						from, to, err := parseDate(eventDate)
 						if err != nil {
 							// when event has no image city locates in date place in html
 							// but date locates right after <h5>
 							if dates := strings.Split(eventDetail[0], "</a></h5>"); len(dates) == 2 {
 								eventCity = clearCity(eventDate)
+								eventCountry = parseCountry(eventDate)
 								from, to, err = parseDate(dates[1])
 							}
 						}
 						if err != nil {
 							l.fuse.Process("PARSE", fmt.Errorf("parse date next event for %#v failed with %#v", band, err))
 						}
+						venue := l.getNextEventVenue(eventHref)
 						events = append(events, store.Event{
-							Band:  toUtf8(band.Name),
-							Title: toUtf8(eventTitle),
-							From:  from,
-							To:    to,
-							City:  toUtf8(eventCity),
-							Link:  eventHref,
-							Img:   l.buildURL(eventImg),
-							Venue: l.getNextEventVenue(eventHref),
+							Band:    toUtf8(band.Name),
+							Title:   toUtf8(eventTitle),
+							From:    from,
+							To:      to,
+							City:    toUtf8(eventCity),
+							Country: toUtf8(eventCountry),
+							Link:    eventHref,
+							Img:     eventImg,
+							Venue:   venue.Name,
+							Address: venue.Address,
 						})
 						l.fuse.Process("PARSE", nil)
 					}
@@ -265,21 +351,58 @@ func (l *CMetalLoader) getNextEvents(band cmetalBand, s *goquery.Selection) ([]s
 	return nil, nil
 }
 
-func (l *CMetalLoader) getNextEventVenue(url string) string {
+// getNextEventVenue returns venue of event from the event's page.
+// Several bands play on the same event, so venues are cached by url
+// to not load the same page for each band.
+func (l *CMetalLoader) getNextEventVenue(url string) cmetalVenue {
+	l.venuesMu.Lock()
+	venue, ok := l.venues[url]
+	l.venuesMu.Unlock()
+	if ok {
+		return venue
+	}
 	doc := l.loadHTMLDocument(url)
 	if doc == nil {
-		return ""
+		return venue
 	}
 	if div := doc.Find("div[itemprop='address']").First(); div != nil {
 		if td := div.Find("td"); td != nil {
 			if ftd := td.First(); ftd != nil && len(ftd.Nodes) > 0 {
 				if venueNode := ftd.Nodes[0].FirstChild; venueNode != nil {
-					return venueNode.Data
+					venue.Name = venueNode.Data
+					venue.Address = parseAddress(ftd, venue.Name)
 				}
 			}
 		}
 	}
-	return ""
+	l.venuesMu.Lock()
+	l.venues[url] = venue
+	l.venuesMu.Unlock()
+	return venue
+}
+
+// parseAddress returns address from the address block of event's page.
+// It uses schema.org's properties if they are or text after venue's name.
+func parseAddress(s *goquery.Selection, venue string) string {
+	parts := make([]string, 0)
+	for _, prop := range []string{"streetAddress", "postalCode", "addressLocality"} {
+		if text := strings.TrimSpace(s.Find("[itemprop='" + prop + "']").First().Text()); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	if len(parts) == 0 {
+		text := strings.TrimPrefix(strings.TrimSpace(s.Text()), venue)
+		parts = strings.FieldsFunc(text, func(r rune) bool {
+			return r == '\n' || r == ','
+		})
+	}
+	address := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.Join(strings.Fields(p), " "); p != "" {
+			address = append(address, toUtf8(p))
+		}
+	}
+	return strings.Join(address, ", ")
 }
 
 // getLastEvents returns array of events whichi have been already from html nodes.
@@ -327,6 +450,15 @@ func (l *CMetalLoader) buildURL(href string) string {
 	return ""
 }
 
+// imageURL returns absolute url of image, relative sources are resolved
+// against the base url.
+func (l *CMetalLoader) imageURL(src string) string {
+	if src == "" || strings.HasPrefix(src, "http") {
+		return src
+	}
+	return l.buildURL(strings.TrimPrefix(src, "/"))
+}
+
 func (l *CMetalLoader) loadHTMLDocument(url string) *goquery.Document {
 	resp, err := l.httpclient.Get(url)
 	if err != nil {
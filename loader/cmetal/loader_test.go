@@ -0,0 +1,47 @@
+package cmetal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/austinov/rocker-bot/config"
+	"github.com/stretchr/testify/assert"
+)
+
+const nextEventsHTML = `<html><body><table><tbody><tr>
+<td><a href="event.php?e=1" title="Metallica"><img src="/images/flyers/1.jpg"/></a><br/>04/05/2017<br/>London - O2 Arena <img src="flags/uk.png" title="United Kingdom"/></td>
+<td><a href="event.php?e=2" title="Download Festival"><img src="http://img.example.com/2.jpg"/></a><br/>09/06/2017<br/>Paris <img src="flags/fr.png" title="France"/></td>
+</tr></tbody></table></body></html>`
+
+const eventHTML = `<html><body><div itemprop="address"><table><tr>
+<td>O2 Arena<br/><span itemprop="streetAddress">Peninsula Square</span></td>
+</tr></table></div></body></html>`
+
+func TestGetNextEvents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(eventHTML))
+	}))
+	defer server.Close()
+
+	l := New(config.CMetalConfig{BaseURL: server.URL + "/"}, nil, nil).(*CMetalLoader)
+	l.venues = make(map[string]cmetalVenue)
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(nextEventsHTML))
+	assert.NoError(t, err)
+
+	events, err := l.getNextEvents(cmetalBand{Id: "1", Name: "Metallica"}, doc.Selection)
+	assert.NoError(t, err)
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, "Metallica", events[0].Title)
+		assert.Equal(t, "London", events[0].City)
+		assert.Equal(t, "United Kingdom", events[0].Country)
+		assert.Equal(t, "O2 Arena", events[0].Venue)
+		assert.Equal(t, server.URL+"/event.php?e=1", events[0].Link)
+		// relative poster is resolved against the base url, absolute one is kept
+		assert.Equal(t, server.URL+"/images/flyers/1.jpg", events[0].Img)
+		assert.Equal(t, "http://img.example.com/2.jpg", events[1].Img)
+	}
+}
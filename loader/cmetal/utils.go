@@ -3,6 +3,7 @@ package cmetal
 import (
 	"errors"
 	"fmt"
+	"path"
 	"regexp"
 	"strings"
 	"time"
@@ -12,6 +13,11 @@ import (
 
 var re = regexp.MustCompile("\\d{1,2}/\\d{1,2}/\\d{4}")
 
+var (
+	reImg  = regexp.MustCompile("<img[^>]*>")
+	reAttr = regexp.MustCompile("(\\w+)=\"([^\"]*)\"")
+)
+
 // parseDate parses date string from concerts-metal.com.
 // It returns begin and end Unix dates
 func parseDate(date string) (int64, int64, error) {
@@ -88,6 +94,59 @@ func parseLastEvents(text string) ([]store.Event, error) {
 	return result, nil
 }
 
+// parseCountry parses country from the flag's image next to the city,
+// e.g. Berlin <img src="images/flags/Germany.png" title="Germany"/>.
+// It returns empty string if there is no flag.
+func parseCountry(html string) string {
+	img := reImg.FindString(html)
+	if img == "" {
+		return ""
+	}
+	attrs := make(map[string]string)
+	for _, m := range reAttr.FindAllStringSubmatch(img, -1) {
+		attrs[strings.ToLower(m[1])] = strings.TrimSpace(m[2])
+	}
+	if attrs["title"] != "" {
+		return attrs["title"]
+	}
+	if attrs["alt"] != "" {
+		return attrs["alt"]
+	}
+	// the flag's image is named by country
+	if src := attrs["src"]; src != "" {
+		name := path.Base(src)
+		name = strings.TrimSuffix(name, path.Ext(name))
+		return strings.Replace(name, "_", " ", -1)
+	}
+	return ""
+}
+
+// parseGenres parses genres from text like "Style : Thrash Metal / Speed Metal".
+// It returns nil if text is not started with the genre's label.
+func parseGenres(text string) []string {
+	text = strings.TrimSpace(text)
+	for _, label := range []string{"Style", "Genre"} {
+		if !strings.HasPrefix(text, label) {
+			continue
+		}
+		idx := strings.Index(text, ":")
+		if idx == -1 || strings.TrimSpace(strings.TrimRight(text[len(label):idx], "s")) != "" {
+			return nil
+		}
+		line := strings.SplitN(text[idx+1:], "\n", 2)[0]
+		genres := make([]string, 0)
+		for _, g := range strings.FieldsFunc(line, func(r rune) bool {
+			return r == '/' || r == ','
+		}) {
+			if g = strings.TrimSpace(g); g != "" {
+				genres = append(genres, toUtf8(g))
+			}
+		}
+		return genres
+	}
+	return nil
+}
+
 func toUtf8(text string) string {
 	bbuf := []byte(text)
 	rbuf := make([]rune, len(bbuf))
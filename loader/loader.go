@@ -1,6 +1,18 @@
 package loader
 
+import (
+	"context"
+
+	"github.com/austinov/rocker-bot/store"
+)
+
 type Loader interface {
-	Start() error
+	// Start runs the loader until ctx is done or the loader is stopped.
+	// It returns error if the loader is crashed, so it may be restarted.
+	Start(ctx context.Context) error
 	Stop()
 }
+
+// Listener is called with events which were added by loader's run,
+// ctx is done when the loader is stopped.
+type Listener func(ctx context.Context, events []store.Event)
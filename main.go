@@ -1,40 +1,162 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/austinov/rocker-bot/api"
 	"github.com/austinov/rocker-bot/bot"
+	"github.com/austinov/rocker-bot/bot/discord"
+	"github.com/austinov/rocker-bot/bot/mattermost"
+	"github.com/austinov/rocker-bot/bot/slack"
+	"github.com/austinov/rocker-bot/bot/telegram"
 	"github.com/austinov/rocker-bot/config"
+	"github.com/austinov/rocker-bot/loader"
 	"github.com/austinov/rocker-bot/loader/cmetal"
 	"github.com/austinov/rocker-bot/store"
+	"github.com/austinov/rocker-bot/store/memory"
 	"github.com/austinov/rocker-bot/store/pg"
+	"github.com/austinov/rocker-bot/store/sqlite"
+	"github.com/austinov/rocker-bot/supervisor"
 )
 
+const defaultShutdownTimeout = 30 * time.Second
+
+var (
+	migrate   bool
+	repl      bool
+	replQuery bool
+)
+
+func init() {
+	flag.BoolVar(&migrate, "migrate", false, "apply pending db migrations and exit")
+	flag.BoolVar(&repl, "repl", false, "read commands from stdin and print replies of the bot without chat")
+	flag.BoolVar(&replQuery, "repl-query", false, "print parsed queries as JSON in REPL")
+}
+
 func main() {
+	flag.Parse()
+
 	cfg := config.GetConfig()
-	if err := cfg.Verify(); err != nil {
+	if migrate {
+		// db migrations are applied while dao is created
+		if err := cfg.DB.Verify(); err != nil {
+			log.Fatal(err)
+		}
+		createDao(cfg.DB).Close()
+		return
+	}
+	if repl {
+		// chat isn't used, so only db configuration is needed
+		if err := cfg.DB.Verify(); err != nil {
+			log.Fatal(err)
+		}
+	} else if err := cfg.Verify(); err != nil {
 		log.Fatal(err)
 	}
 
 	dao := createDao(cfg.DB)
 	defer dao.Close()
 
-	l := cmetal.New(cfg.CMetal, dao)
-	// start loader in separate go-routine
-	//go l.Start()
+	if cfg.DB.Aliases != "" {
+		if err := store.LoadAliases(cfg.DB.Aliases, dao); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if repl {
+		if err := bot.REPL(cfg.Bot, dao, os.Stdin, os.Stdout, replQuery); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	b := bot.New(cfg.Bot, dao, createTransport(cfg.Bot))
 
-	b := bot.New(cfg.Bot, dao)
-	// start bot and block until return
-	b.Start()
-	// stop loader
-	l.Stop()
+	// new events of the loaders are posted to bands' followers
+	loaders := map[string]loader.Loader{
+		"cmetal loader": cmetal.New(cfg.CMetal, dao, b.NotifyNewEvents),
+	}
+
+	// ctx is cancelled on SIGINT or SIGTERM
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		s := <-signals
+		log.Printf("Got signal %s, shutting down.\n", s)
+		cancel()
+	}()
+
+	// bot, loaders and API are run together, crashed loaders and API are restarted
+	s := supervisor.New()
+	s.Add("bot", func(ctx context.Context) error {
+		b.Start(ctx)
+		return nil
+	}, false)
+	for name, l := range loaders {
+		s.Add(name, l.Start, true)
+	}
+	if cfg.API.Enabled {
+		s.Add("api", api.New(cfg.API, dao).Start, true)
+	}
+
+	// start supervisor and block until it's stopped
+	stopped := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		timeout := cfg.ShutdownTimeout
+		if timeout == 0 {
+			timeout = defaultShutdownTimeout
+		}
+		select {
+		case <-stopped:
+		case <-time.After(timeout):
+			log.Println("Shutdown timeout is expired.")
+			for _, st := range s.Status() {
+				log.Printf("%s is %s since %s.\n", st.Name, st.State, st.Since.Format(time.RFC3339))
+			}
+			// the db isn't closed under running workers
+			os.Exit(1)
+		}
+	}
+	// stop loaders
+	for _, l := range loaders {
+		l.Stop()
+	}
 }
 
 func createDao(cfg config.DBConfig) store.Dao {
 	switch cfg.Type {
 	case "pg":
 		return pg.New(cfg)
+	case "sqlite":
+		return sqlite.New(cfg)
+	case "memory":
+		return memory.New(cfg)
 	}
 	log.Fatal("Unknown db type " + cfg.Type)
 	return nil
 }
+
+func createTransport(cfg config.BotConfig) bot.Transport {
+	switch cfg.Transport {
+	case "telegram":
+		return telegram.New(cfg.Telegram)
+	case "discord":
+		return discord.New(cfg.Discord)
+	case "mattermost":
+		return mattermost.New(cfg.Mattermost)
+	}
+	return slack.New(cfg)
+}
@@ -0,0 +1,41 @@
+package store
+
+import (
+	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Aliases is a seed of band's and city's aliases.
+// Keys are names, values are lists of aliases.
+type Aliases struct {
+	Bands  map[string][]string `yaml:"bands"`
+	Cities map[string][]string `yaml:"cities"`
+}
+
+// LoadAliases reads aliases from YAML file and adds them into dao.
+func LoadAliases(path string, dao Dao) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var aliases Aliases
+	if err = yaml.Unmarshal(data, &aliases); err != nil {
+		return err
+	}
+	for band, names := range aliases.Bands {
+		for _, alias := range names {
+			if err := dao.AddBandAlias(band, alias); err != nil {
+				return err
+			}
+		}
+	}
+	for city, names := range aliases.Cities {
+		for _, alias := range names {
+			if err := dao.AddCityAlias(city, alias); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
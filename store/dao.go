@@ -1,16 +1,81 @@
 package store
 
-import "io"
+import (
+	"errors"
+	"io"
+)
+
+// MinSimilarity is a minimal similarity of names returned by FindBands and
+// FindCities. It is the same as default threshold of pg_trgm.
+const MinSimilarity = 0.3
+
+// ErrNegativeOffset is returned by paged methods if offset is negative.
+var ErrNegativeOffset = errors.New("offset must not be negative")
 
 type Dao interface {
 	// Embedded a Closer interface
 	io.Closer
 
-	// AddBandEvents saves band's events
-	AddBandEvents(events []Event) error
+	// AddBandEvents saves band's events and returns the ones which were
+	// not saved before. Saved events of band which are missed in events
+	// are removed. Band's and city's aliases are resolved to the known names.
+	AddBandEvents(events []Event) ([]Event, error)
 
-	// GetEvents returns band's events in city for period.
+	// GetEvents returns band's events in city or country for period.
 	// Period is two Unix time in seconds.
 	// It returns empty array if no events.
-	GetEvents(band string, city string, from, to int64, offset, limit int) ([]Event, error)
+	// Band and city may be names or aliases.
+	// It returns ErrNegativeOffset if offset is negative.
+	GetEvents(filter Filter, offset, limit int) ([]Event, error)
+
+	// AddBandAlias adds the alias of band. The band is added if not exist.
+	AddBandAlias(band, alias string) error
+
+	// AddCityAlias adds the alias of city. The city is added if not exist.
+	AddCityAlias(city, alias string) error
+
+	// FindBands returns bands with names similar to the name,
+	// the most similar first.
+	FindBands(name string, limit int) ([]Match, error)
+
+	// FindCities returns cities with names similar to the name,
+	// the most similar first.
+	FindCities(name string, limit int) ([]Match, error)
+
+	// GetBands returns names of bands ordered by name starting from offset.
+	// It returns ErrNegativeOffset if offset is negative.
+	GetBands(offset, limit int) ([]string, error)
+
+	// GetCities returns names of cities ordered by name starting from offset.
+	// It returns ErrNegativeOffset if offset is negative.
+	GetCities(offset, limit int) ([]string, error)
+
+	// Follow subscribes user in channel to band's events.
+	// The band is added if not exist.
+	Follow(s Subscription) error
+
+	// Unfollow removes subscription of user in channel to band's events.
+	Unfollow(s Subscription) error
+
+	// GetSubscriptions returns subscriptions of user in channel
+	// ordered by band's name.
+	GetSubscriptions(user, channel string) ([]Subscription, error)
+
+	// GetFollowers returns subscriptions to band's events.
+	// Band may be name or alias.
+	GetFollowers(band string) ([]Subscription, error)
+
+	// AddWatch saves watch of channel for the city, next run of
+	// existing watch is replaced. The city is added if not exist.
+	AddWatch(w Watch) error
+
+	// RemoveWatch removes watch of channel for the city.
+	RemoveWatch(channel, city string) error
+
+	// GetWatches returns watches of channel ordered by city's name.
+	GetWatches(channel string) ([]Watch, error)
+
+	// GetDueWatches returns watches which next run is not after the time
+	// (Unix time in seconds) ordered by next run.
+	GetDueWatches(at int64) ([]Watch, error)
 }
@@ -0,0 +1,539 @@
+package memory
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/austinov/rocker-bot/common"
+	"github.com/austinov/rocker-bot/config"
+	"github.com/austinov/rocker-bot/store"
+)
+
+// eventKey identifies event with the same fields as events are grouped in pg's
+// vw_events query, so bands on the same event are merged into one.
+type eventKey struct {
+	title    string
+	from, to int64
+	city     string
+	country  string
+	venue    string
+	address  string
+	link     string
+	img      string
+}
+
+// venueKey identifies venue by name in the city.
+type venueKey struct {
+	name string // lower name
+	city string // lower city's name
+}
+
+// subscriptionKey identifies user's subscription in the channel to the band.
+type subscriptionKey struct {
+	user    string
+	channel string
+	band    string // lower band's name
+}
+
+// watchKey identifies channel's watch for the city.
+type watchKey struct {
+	channel string
+	city    string // lower city's name
+}
+
+type venue struct {
+	name    string
+	address string
+}
+
+// Dao keeps bands, cities and events in memory. It's useful for tests and
+// for local runs without PostgreSQL.
+type Dao struct {
+	mu          sync.RWMutex
+	bands       map[string]string // lower name -> name
+	cities      map[string]string // lower name -> name
+	countries   map[string]string // lower name -> name
+	cityCountry map[string]string // lower city's name -> lower country's name
+	venues      map[venueKey]venue
+	genres      map[string]string        // lower name -> name
+	bandGenres  map[string][]string      // lower band's name -> lower genre's names
+	bandAliases map[string]string        // lower alias -> lower band's name
+	cityAliases map[string]string        // lower alias -> lower city's name
+	events      map[string][]store.Event // lower band's name -> band's events
+	subscribers map[subscriptionKey]struct{}
+	watches     map[watchKey]int64 // watch -> next run
+}
+
+func New(cfg config.DBConfig) store.Dao {
+	return &Dao{
+		bands:       make(map[string]string),
+		cities:      make(map[string]string),
+		countries:   make(map[string]string),
+		cityCountry: make(map[string]string),
+		venues:      make(map[venueKey]venue),
+		genres:      make(map[string]string),
+		bandGenres:  make(map[string][]string),
+		bandAliases: make(map[string]string),
+		cityAliases: make(map[string]string),
+		events:      make(map[string][]store.Event),
+		subscribers: make(map[subscriptionKey]struct{}),
+		watches:     make(map[watchKey]int64),
+	}
+}
+
+func (d *Dao) Close() error {
+	return nil
+}
+
+func (d *Dao) AddBandEvents(events []store.Event) ([]store.Event, error) {
+	if len(events) == 0 {
+		return nil, nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// add band if not exist
+	bandKey := resolve(d.bands, d.bandAliases, events[0].Band)
+	bandName, ok := d.bands[bandKey]
+	if !ok {
+		bandName = events[0].Band
+		d.bands[bandKey] = bandName
+	}
+	// replace band's genres if they are known
+	if genres := events[0].Genres; len(genres) > 0 {
+		keys := make([]string, 0, len(genres))
+		for _, genre := range genres {
+			genreKey := strings.ToLower(genre)
+			if _, ok := d.genres[genreKey]; !ok {
+				d.genres[genreKey] = genre
+			}
+			keys = appendDistinct(keys, genreKey)
+		}
+		d.bandGenres[bandKey] = keys
+	}
+	// saved events are replaced, the ones which are not saved before are new
+	oldEvents := d.events[bandKey]
+	bandEvents := make([]store.Event, 0, len(events))
+	newEvents := make([]store.Event, 0)
+	for _, e := range events {
+		event := e
+		// add city if not exist
+		cityKey := resolve(d.cities, d.cityAliases, event.City)
+		cityName, ok := d.cities[cityKey]
+		if !ok {
+			cityName = event.City
+			d.cities[cityKey] = cityName
+		}
+		// link city with country if it's known
+		if event.Country != "" {
+			countryKey := strings.ToLower(event.Country)
+			if _, ok := d.countries[countryKey]; !ok {
+				d.countries[countryKey] = event.Country
+			}
+			d.cityCountry[cityKey] = countryKey
+		}
+		// add venue if not exist
+		if event.Venue != "" {
+			vk := venueKey{strings.ToLower(event.Venue), cityKey}
+			v, ok := d.venues[vk]
+			if !ok {
+				v.name = event.Venue
+			}
+			if event.Address != "" {
+				v.address = event.Address
+			}
+			d.venues[vk] = v
+			event.Venue = v.name
+		}
+		event.Band = bandName
+		event.Genres = nil
+		event.City = cityName
+		event.Country = ""
+		event.Address = ""
+		// add event
+		if !containsEvent(bandEvents, event) {
+			bandEvents = append(bandEvents, event)
+			if !containsEvent(oldEvents, event) {
+				newEvents = append(newEvents, e)
+			}
+		}
+	}
+	d.events[bandKey] = bandEvents
+	return newEvents, nil
+}
+
+func (d *Dao) GetEvents(f store.Filter, offset, limit int) ([]store.Event, error) {
+	if offset < 0 {
+		return nil, store.ErrNegativeOffset
+	}
+	d.mu.RLock()
+	var band, city string
+	if f.Band != "" {
+		band = resolve(d.bands, d.bandAliases, f.Band)
+	}
+	if f.City != "" {
+		city = resolve(d.cities, d.cityAliases, f.City)
+	}
+	country := strings.ToLower(f.Country)
+	venueName := strings.ToLower(f.Venue)
+	genre := strings.ToLower(f.Genre)
+	keys := make([]eventKey, 0)
+	bands := make(map[eventKey][]string)
+	for bandKey, bandEvents := range d.events {
+		if band != "" && band != bandKey {
+			continue
+		}
+		if genre != "" && !hasGenre(d.bandGenres[bandKey], genre) {
+			continue
+		}
+		for _, e := range bandEvents {
+			cityKey := strings.ToLower(e.City)
+			if city != "" && city != cityKey {
+				continue
+			}
+			countryKey := d.cityCountry[cityKey]
+			if country != "" && country != countryKey {
+				continue
+			}
+			if venueName != "" && venueName != strings.ToLower(e.Venue) {
+				continue
+			}
+			if e.From < f.From || (f.To != 0 && e.To > f.To) {
+				continue
+			}
+			v := d.venues[venueKey{strings.ToLower(e.Venue), cityKey}]
+			k := eventKey{e.Title, e.From, e.To, e.City, d.countries[countryKey], v.name, v.address, e.Link, e.Img}
+			if _, ok := bands[k]; !ok {
+				keys = append(keys, k)
+			}
+			bands[k] = appendDistinct(bands[k], e.Band)
+		}
+	}
+	d.mu.RUnlock()
+
+	sort.Sort(byBeginDate(keys))
+
+	events := make([]store.Event, 0)
+	for i := offset; i < len(keys) && len(events) < limit; i++ {
+		k := keys[i]
+		names := bands[k]
+		sort.Strings(names)
+		events = append(events, store.Event{
+			Band:    strings.Join(names, ", "),
+			Title:   k.title,
+			From:    k.from,
+			To:      k.to,
+			City:    k.city,
+			Country: k.country,
+			Venue:   k.venue,
+			Address: k.address,
+			Link:    k.link,
+			Img:     k.img,
+		})
+	}
+	return events, nil
+}
+
+func (d *Dao) AddBandAlias(band, alias string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	addAlias(d.bands, d.bandAliases, band, alias)
+	return nil
+}
+
+func (d *Dao) AddCityAlias(city, alias string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	addAlias(d.cities, d.cityAliases, city, alias)
+	return nil
+}
+
+func (d *Dao) Follow(s store.Subscription) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	bandKey := resolve(d.bands, d.bandAliases, s.Band)
+	if _, ok := d.bands[bandKey]; !ok {
+		d.bands[bandKey] = s.Band
+	}
+	d.subscribers[subscriptionKey{s.User, s.Channel, bandKey}] = struct{}{}
+	return nil
+}
+
+func (d *Dao) Unfollow(s store.Subscription) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	bandKey := resolve(d.bands, d.bandAliases, s.Band)
+	delete(d.subscribers, subscriptionKey{s.User, s.Channel, bandKey})
+	return nil
+}
+
+func (d *Dao) GetSubscriptions(user, channel string) ([]store.Subscription, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	subscriptions := make([]store.Subscription, 0)
+	for k := range d.subscribers {
+		if k.user == user && k.channel == channel {
+			subscriptions = append(subscriptions, store.Subscription{
+				User:    k.user,
+				Channel: k.channel,
+				Band:    d.bands[k.band],
+			})
+		}
+	}
+	sort.Sort(byBand(subscriptions))
+	return subscriptions, nil
+}
+
+func (d *Dao) GetFollowers(band string) ([]store.Subscription, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	bandKey := resolve(d.bands, d.bandAliases, band)
+	subscriptions := make([]store.Subscription, 0)
+	for k := range d.subscribers {
+		if k.band == bandKey {
+			subscriptions = append(subscriptions, store.Subscription{
+				User:    k.user,
+				Channel: k.channel,
+				Band:    d.bands[k.band],
+			})
+		}
+	}
+	sort.Sort(byChannel(subscriptions))
+	return subscriptions, nil
+}
+
+func (d *Dao) AddWatch(w store.Watch) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	cityKey := resolve(d.cities, d.cityAliases, w.City)
+	if _, ok := d.cities[cityKey]; !ok {
+		d.cities[cityKey] = w.City
+	}
+	d.watches[watchKey{w.Channel, cityKey}] = w.NextRun
+	return nil
+}
+
+func (d *Dao) RemoveWatch(channel, city string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	cityKey := resolve(d.cities, d.cityAliases, city)
+	delete(d.watches, watchKey{channel, cityKey})
+	return nil
+}
+
+func (d *Dao) GetWatches(channel string) ([]store.Watch, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	watches := make([]store.Watch, 0)
+	for k, nextRun := range d.watches {
+		if k.channel == channel {
+			watches = append(watches, store.Watch{
+				Channel: k.channel,
+				City:    d.cities[k.city],
+				NextRun: nextRun,
+			})
+		}
+	}
+	sort.Sort(byCity(watches))
+	return watches, nil
+}
+
+func (d *Dao) GetDueWatches(at int64) ([]store.Watch, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	watches := make([]store.Watch, 0)
+	for k, nextRun := range d.watches {
+		if nextRun <= at {
+			watches = append(watches, store.Watch{
+				Channel: k.channel,
+				City:    d.cities[k.city],
+				NextRun: nextRun,
+			})
+		}
+	}
+	sort.Sort(byNextRun(watches))
+	return watches, nil
+}
+
+// resolve returns key of the name in names, the name may be an alias.
+func resolve(names, aliases map[string]string, name string) string {
+	key := strings.ToLower(name)
+	if _, ok := names[key]; ok {
+		return key
+	}
+	if k, ok := aliases[key]; ok {
+		return k
+	}
+	return key
+}
+
+// addAlias adds the name if not exist and links the alias to it.
+func addAlias(names, aliases map[string]string, name, alias string) {
+	key := resolve(names, aliases, name)
+	if _, ok := names[key]; !ok {
+		names[key] = name
+	}
+	aliases[strings.ToLower(alias)] = key
+}
+
+func (d *Dao) FindBands(name string, limit int) ([]store.Match, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return findNames(d.bands, name, limit), nil
+}
+
+func (d *Dao) FindCities(name string, limit int) ([]store.Match, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return findNames(d.cities, name, limit), nil
+}
+
+func (d *Dao) GetBands(offset, limit int) ([]string, error) {
+	if offset < 0 {
+		return nil, store.ErrNegativeOffset
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return listNames(d.bands, offset, limit), nil
+}
+
+func (d *Dao) GetCities(offset, limit int) ([]string, error) {
+	if offset < 0 {
+		return nil, store.ErrNegativeOffset
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return listNames(d.cities, offset, limit), nil
+}
+
+// listNames returns names ordered by their lower names starting from offset.
+func listNames(names map[string]string, offset, limit int) []string {
+	keys := make([]string, 0, len(names))
+	for k := range names {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	list := make([]string, 0)
+	for i := offset; i < len(keys) && len(list) < limit; i++ {
+		list = append(list, names[keys[i]])
+	}
+	return list
+}
+
+// findNames returns names similar to the name, the most similar first.
+func findNames(names map[string]string, name string, limit int) []store.Match {
+	matches := make([]store.Match, 0)
+	for _, n := range names {
+		if sml := common.Similarity(n, name); sml >= store.MinSimilarity {
+			matches = append(matches, store.Match{
+				Name:       n,
+				Similarity: sml,
+			})
+		}
+	}
+	sort.Sort(bySimilarity(matches))
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// containsEvent reports whether events has an event with the same title,
+// dates and city as e has (it's a unique key of event in pg).
+func containsEvent(events []store.Event, e store.Event) bool {
+	for _, ev := range events {
+		if ev.Title == e.Title && ev.From == e.From && ev.To == e.To && ev.City == e.City {
+			return true
+		}
+	}
+	return false
+}
+
+// hasGenre reports whether any of genres contains the genre.
+func hasGenre(genres []string, genre string) bool {
+	for _, g := range genres {
+		if strings.Contains(g, genre) {
+			return true
+		}
+	}
+	return false
+}
+
+func appendDistinct(names []string, name string) []string {
+	for _, n := range names {
+		if n == name {
+			return names
+		}
+	}
+	return append(names, name)
+}
+
+// byBeginDate sorts events by begin date. Other fields are used to
+// keep the order stable between calls with different offsets.
+type byBeginDate []eventKey
+
+func (s byBeginDate) Len() int      { return len(s) }
+func (s byBeginDate) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byBeginDate) Less(i, j int) bool {
+	if s[i].from != s[j].from {
+		return s[i].from < s[j].from
+	}
+	if s[i].title != s[j].title {
+		return s[i].title < s[j].title
+	}
+	return s[i].city < s[j].city
+}
+
+// bySimilarity sorts matches by similarity in descending order.
+type bySimilarity []store.Match
+
+func (s bySimilarity) Len() int      { return len(s) }
+func (s bySimilarity) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s bySimilarity) Less(i, j int) bool {
+	if s[i].Similarity != s[j].Similarity {
+		return s[i].Similarity > s[j].Similarity
+	}
+	return s[i].Name < s[j].Name
+}
+
+// byBand sorts subscriptions by band's name.
+type byBand []store.Subscription
+
+func (s byBand) Len() int           { return len(s) }
+func (s byBand) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byBand) Less(i, j int) bool { return s[i].Band < s[j].Band }
+
+// byChannel sorts subscriptions by channel and user.
+type byChannel []store.Subscription
+
+func (s byChannel) Len() int      { return len(s) }
+func (s byChannel) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byChannel) Less(i, j int) bool {
+	if s[i].Channel != s[j].Channel {
+		return s[i].Channel < s[j].Channel
+	}
+	return s[i].User < s[j].User
+}
+
+// byCity sorts watches by city's name.
+type byCity []store.Watch
+
+func (s byCity) Len() int           { return len(s) }
+func (s byCity) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byCity) Less(i, j int) bool { return s[i].City < s[j].City }
+
+// byNextRun sorts watches by next run.
+type byNextRun []store.Watch
+
+func (s byNextRun) Len() int      { return len(s) }
+func (s byNextRun) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byNextRun) Less(i, j int) bool {
+	if s[i].NextRun != s[j].NextRun {
+		return s[i].NextRun < s[j].NextRun
+	}
+	if s[i].Channel != s[j].Channel {
+		return s[i].Channel < s[j].Channel
+	}
+	return s[i].City < s[j].City
+}
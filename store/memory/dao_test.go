@@ -0,0 +1,15 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/austinov/rocker-bot/config"
+	"github.com/austinov/rocker-bot/store"
+	"github.com/austinov/rocker-bot/store/storetest"
+)
+
+func TestDao(t *testing.T) {
+	storetest.Run(t, func() store.Dao {
+		return New(config.DBConfig{Type: "memory"})
+	})
+}
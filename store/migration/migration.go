@@ -0,0 +1,83 @@
+// Package migration applies numbered migrations of db schema
+// and records the applied version in schema_version table.
+package migration
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	versionCreate = `
+	CREATE TABLE IF NOT EXISTS schema_version (
+	    "version"    integer NOT NULL,
+	    "applied_at" bigint NOT NULL
+	)`
+
+	versionSelect = `
+	    SELECT COALESCE(MAX(version), 0)
+	    FROM schema_version`
+
+	// version is an integer, so it's safe to build the query and
+	// it does not depend on placeholders of db driver.
+	versionInsert = `
+	    INSERT INTO schema_version (version, applied_at)
+	    VALUES (%d, %d)`
+)
+
+// Migration is a script to move db schema to the version.
+type Migration struct {
+	Version int
+	Script  string
+}
+
+// Version returns the current version of db schema.
+func Version(db *sql.DB) (int, error) {
+	if _, err := db.Exec(versionCreate); err != nil {
+		return 0, err
+	}
+	var version int
+	if err := db.QueryRow(versionSelect).Scan(&version); err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// Up applies migrations with version greater than the current one.
+// Migrations must be sorted by version. Each migration is applied
+// in its own transaction.
+func Up(db *sql.DB, migrations []Migration) error {
+	current, err := Version(db)
+	if err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+		log.Printf("Apply db migration %d\n", m.Version)
+		if err := apply(db, m); err != nil {
+			return fmt.Errorf("migration %d failed with %#v", m.Version, err)
+		}
+		current = m.Version
+	}
+	return nil
+}
+
+func apply(db *sql.DB, m Migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err = tx.Exec(m.Script); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err = tx.Exec(fmt.Sprintf(versionInsert, m.Version, time.Now().Unix())); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
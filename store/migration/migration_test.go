@@ -0,0 +1,38 @@
+package migration
+
+import (
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUp(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	migrations := []Migration{
+		{Version: 1, Script: `CREATE TABLE band (name varchar(100))`},
+		{Version: 2, Script: `ALTER TABLE band ADD COLUMN genre varchar(100)`},
+	}
+	assert.NoError(t, Up(db, migrations[:1]))
+	version, err := Version(db)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, version)
+
+	// applied migrations are skipped
+	assert.NoError(t, Up(db, migrations))
+	version, err = Version(db)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, version)
+
+	// failed migration does not change the version
+	migrations = append(migrations, Migration{Version: 3, Script: `ALTER TABLE unknown ADD COLUMN x integer`})
+	assert.Error(t, Up(db, migrations))
+	version, err = Version(db)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, version)
+}
@@ -1,12 +1,50 @@
 package store
 
 type Event struct {
-	Band  string
-	Title string
-	From  int64
-	To    int64
-	City  string
-	Venue string
-	Link  string
-	Img   string
+	Band    string
+	Genres  []string // genres of band, they are saved with band's events
+	Title   string
+	From    int64
+	To      int64
+	City    string
+	Country string
+	Venue   string
+	Address string // address of venue
+	Link    string
+	Img     string
+}
+
+// Filter is a set of conditions to select events.
+// Empty names and zero dates are not used to filter events.
+type Filter struct {
+	Band    string
+	City    string
+	Country string
+	Venue   string
+	Genre   string // part of genre's name, e.g. doom matches Doom Metal
+	From    int64
+	To      int64
+}
+
+// Match is a name of band or city found by similarity to the requested name.
+// Similarity is a number from 0 to 1, where 1 means the same names.
+type Match struct {
+	Name       string
+	Similarity float64
+}
+
+// Subscription is a subscription of Slack's user in the channel
+// to new events of the band.
+type Subscription struct {
+	User    string
+	Channel string
+	Band    string
+}
+
+// Watch is a watch of channel for events in the city, digest of events
+// is posted at NextRun (Unix time in seconds).
+type Watch struct {
+	Channel string
+	City    string
+	NextRun int64
 }
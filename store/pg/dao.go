@@ -9,15 +9,17 @@ import (
 
 	"github.com/austinov/rocker-bot/config"
 	"github.com/austinov/rocker-bot/store"
+	"github.com/austinov/rocker-bot/store/migration"
 	_ "github.com/lib/pq"
 )
 
 const (
 	bandInsert = `
 	WITH s AS (
-	    SELECT id
-	    FROM band
-	    WHERE lower(name) = $1
+	    (SELECT id FROM band WHERE lower(name) = $1)
+	    UNION ALL
+	    (SELECT band_id FROM band_alias WHERE lower(alias) = $1)
+	    LIMIT 1
 	), i as (
 	    INSERT INTO band (name)
 	    SELECT $2
@@ -29,12 +31,28 @@ const (
 	SELECT id FROM s`
 
 	cityInsert = `
+	WITH s AS (
+	    (SELECT id FROM city WHERE lower(name) = $1)
+	    UNION ALL
+	    (SELECT city_id FROM city_alias WHERE lower(alias) = $1)
+	    LIMIT 1
+	), i as (
+	    INSERT INTO city (name)
+	    SELECT $2
+	    WHERE NOT EXISTS (SELECT 1 FROM s)
+	    RETURNING id
+	)
+	SELECT id FROM i
+	UNION ALL
+	SELECT id FROM s`
+
+	countryInsert = `
 	WITH s AS (
 	    SELECT id
-	    FROM city
+	    FROM country
 	    WHERE lower(name) = $1
 	), i as (
-	    INSERT INTO city (name)
+	    INSERT INTO country (name)
 	    SELECT $2
 	    WHERE NOT EXISTS (SELECT 1 FROM s)
 	    RETURNING id
@@ -43,35 +61,226 @@ const (
 	UNION ALL
 	SELECT id FROM s`
 
-	eventsClear = `
-	    DELETE FROM event
+	cityCountryUpdate = `
+	    UPDATE city
+		SET country_id = $2
+		WHERE id = $1`
+
+	venueInsert = `
+	WITH s AS (
+	    SELECT id
+	    FROM venue
+	    WHERE lower(name) = $1 AND city_id = $3
+	), u AS (
+	    UPDATE venue
+	    SET address = $4
+	    WHERE id IN (SELECT id FROM s) AND $4 <> ''
+	), i as (
+	    INSERT INTO venue (name, city_id, address)
+	    SELECT $2, $3, $4
+	    WHERE NOT EXISTS (SELECT 1 FROM s)
+	    RETURNING id
+	)
+	SELECT id FROM i
+	UNION ALL
+	SELECT id FROM s`
+
+	genreInsert = `
+	WITH s AS (
+	    SELECT id
+	    FROM genre
+	    WHERE lower(name) = $1
+	), i as (
+	    INSERT INTO genre (name)
+	    SELECT $2
+	    WHERE NOT EXISTS (SELECT 1 FROM s)
+	    RETURNING id
+	)
+	SELECT id FROM i
+	UNION ALL
+	SELECT id FROM s`
+
+	bandGenresClear = `
+	    DELETE FROM band_genre
 		WHERE band_id = $1`
 
+	bandGenreInsert = `
+	    INSERT INTO band_genre (band_id, genre_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`
+
+	// eventsClearStale removes band's events which ids are not in
+	// the comma separated list of ids.
+	eventsClearStale = `
+	    DELETE FROM event
+		WHERE band_id = $1 AND NOT (id = ANY(string_to_array($2, ',')::INTEGER[]))`
+
+	// eventInsert inserts event if not exist or updates the existing one,
+	// it returns event's id and true if the event is new.
 	eventInsert = `
-	    INSERT INTO event(title, begin_dt, end_dt, band_id, city_id, venue, link, img)
-		SELECT $1::VARCHAR, $2, $3, $4, $5, $6, $7, $8
-		WHERE NOT EXISTS(
-			SELECT id
-			FROM event
-			WHERE title = $1 AND begin_dt = $2 AND end_dt = $3 AND band_id = $4 AND city_id = $5
-		)`
+	WITH s AS (
+	    SELECT id
+	    FROM event
+	    WHERE title = $1 AND begin_dt = $2 AND end_dt = $3 AND band_id = $4 AND city_id = $5
+	), u AS (
+	    UPDATE event
+	    SET venue_id = $6, link = $7, img = $8
+	    WHERE id IN (SELECT id FROM s)
+	), i AS (
+	    INSERT INTO event(title, begin_dt, end_dt, band_id, city_id, venue_id, link, img)
+	    SELECT $1::VARCHAR, $2, $3, $4, $5, $6, $7, $8
+	    WHERE NOT EXISTS (SELECT 1 FROM s)
+	    RETURNING id
+	)
+	SELECT id, true FROM i
+	UNION ALL
+	SELECT id, false FROM s`
 
 	eventsBandInCity = `
-	    SELECT title, begin_dt, end_dt, city_name, venue, link, img, string_agg(DISTINCT band_name, ', ') AS bands
+	    SELECT title, begin_dt, end_dt, city_name, COALESCE(country_name, ''), COALESCE(venue_name, ''), COALESCE(venue_address, ''),
+		       link, img, string_agg(DISTINCT band_name, ', ') AS bands
 		FROM vw_events
-		WHERE lower(band_name) = COALESCE($1, lower(band_name)) AND 
-		      lower(city_name) = COALESCE($2, lower(city_name)) AND
-			  begin_dt >= $3 AND end_dt <= $4
-		GROUP BY title, begin_dt, end_dt, city_name, venue, link, img
-		ORDER BY begin_dt OFFSET $5 LIMIT $6`
+		WHERE ($1::VARCHAR IS NULL OR band_id IN (
+		          SELECT id FROM band WHERE lower(name) = $1
+		          UNION
+		          SELECT band_id FROM band_alias WHERE lower(alias) = $1)) AND
+		      ($2::VARCHAR IS NULL OR city_id IN (
+		          SELECT id FROM city WHERE lower(name) = $2
+		          UNION
+		          SELECT city_id FROM city_alias WHERE lower(alias) = $2)) AND
+		      ($3::VARCHAR IS NULL OR lower(country_name) = $3) AND
+		      ($4::VARCHAR IS NULL OR lower(venue_name) = $4) AND
+		      ($5::VARCHAR IS NULL OR band_id IN (
+		          SELECT bg.band_id
+		          FROM band_genre bg
+		              JOIN genre g ON bg.genre_id = g.id
+		          WHERE strpos(lower(g.name), $5) > 0)) AND
+			  begin_dt >= $6 AND ($7::BIGINT = 0 OR end_dt <= $7)
+		GROUP BY title, begin_dt, end_dt, city_name, country_name, venue_name, venue_address, link, img
+		ORDER BY begin_dt OFFSET $8 LIMIT $9`
+
+	bandAliasClear = `
+	    DELETE FROM band_alias
+		WHERE lower(alias) = $1`
+
+	bandAliasInsert = `
+	    INSERT INTO band_alias(alias, band_id)
+		VALUES ($1, $2)`
+
+	cityAliasClear = `
+	    DELETE FROM city_alias
+		WHERE lower(alias) = $1`
+
+	cityAliasInsert = `
+	    INSERT INTO city_alias(alias, city_id)
+		VALUES ($1, $2)`
+
+	bandsSimilar = `
+	    SELECT name, similarity(unaccent(lower(name)), unaccent(lower($1))) AS sml
+		FROM band
+		WHERE similarity(unaccent(lower(name)), unaccent(lower($1))) >= $2
+		ORDER BY sml DESC, name LIMIT $3`
+
+	citiesSimilar = `
+	    SELECT name, similarity(unaccent(lower(name)), unaccent(lower($1))) AS sml
+		FROM city
+		WHERE similarity(unaccent(lower(name)), unaccent(lower($1))) >= $2
+		ORDER BY sml DESC, name LIMIT $3`
+
+	bandsList = `
+	    SELECT name
+		FROM band
+		ORDER BY lower(name) OFFSET $1 LIMIT $2`
+
+	citiesList = `
+	    SELECT name
+		FROM city
+		ORDER BY lower(name) OFFSET $1 LIMIT $2`
+
+	subscriptionInsert = `
+	    INSERT INTO subscription (user_id, channel_id, band_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING`
+
+	subscriptionDelete = `
+	    DELETE FROM subscription
+		WHERE user_id = $1 AND channel_id = $2 AND band_id IN (
+		    SELECT id FROM band WHERE lower(name) = $3
+		    UNION
+		    SELECT band_id FROM band_alias WHERE lower(alias) = $3)`
+
+	subscriptionsOfUser = `
+	    SELECT s.user_id, s.channel_id, b.name
+		FROM subscription s
+		    JOIN band b ON s.band_id = b.id
+		WHERE s.user_id = $1 AND s.channel_id = $2
+		ORDER BY b.name`
+
+	subscriptionsOfBand = `
+	    SELECT s.user_id, s.channel_id, b.name
+		FROM subscription s
+		    JOIN band b ON s.band_id = b.id
+		WHERE s.band_id IN (
+		    SELECT id FROM band WHERE lower(name) = $1
+		    UNION
+		    SELECT band_id FROM band_alias WHERE lower(alias) = $1)
+		ORDER BY s.channel_id, s.user_id`
+
+	watchInsert = `
+	    INSERT INTO watch (channel_id, city_id, next_run)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (channel_id, city_id) DO UPDATE SET next_run = $3`
+
+	watchDelete = `
+	    DELETE FROM watch
+		WHERE channel_id = $1 AND city_id IN (
+		    SELECT id FROM city WHERE lower(name) = $2
+		    UNION
+		    SELECT city_id FROM city_alias WHERE lower(alias) = $2)`
+
+	watchesOfChannel = `
+	    SELECT w.channel_id, c.name, w.next_run
+		FROM watch w
+		    JOIN city c ON w.city_id = c.id
+		WHERE w.channel_id = $1
+		ORDER BY c.name`
+
+	watchesDue = `
+	    SELECT w.channel_id, c.name, w.next_run
+		FROM watch w
+		    JOIN city c ON w.city_id = c.id
+		WHERE w.next_run <= $1
+		ORDER BY w.next_run, w.channel_id, c.name`
 )
 
 var (
 	bandInsertStmt       *sql.Stmt
 	cityInsertStmt       *sql.Stmt
+	countryInsertStmt    *sql.Stmt
+	cityCountryStmt      *sql.Stmt
+	venueInsertStmt      *sql.Stmt
+	genreInsertStmt      *sql.Stmt
+	bandGenresClearStmt  *sql.Stmt
+	bandGenreInsertStmt  *sql.Stmt
 	eventsClearStmt      *sql.Stmt
 	eventsInsertStmt     *sql.Stmt
 	eventsBandInCityStmt *sql.Stmt
+	bandAliasClearStmt   *sql.Stmt
+	bandAliasInsertStmt  *sql.Stmt
+	cityAliasClearStmt   *sql.Stmt
+	cityAliasInsertStmt  *sql.Stmt
+	bandsSimilarStmt     *sql.Stmt
+	citiesSimilarStmt    *sql.Stmt
+	bandsListStmt        *sql.Stmt
+	citiesListStmt       *sql.Stmt
+	subscriptionInsStmt  *sql.Stmt
+	subscriptionDelStmt  *sql.Stmt
+	subscriptionsUsrStmt *sql.Stmt
+	subscriptionsBndStmt *sql.Stmt
+	watchInsertStmt      *sql.Stmt
+	watchDeleteStmt      *sql.Stmt
+	watchesOfChannelStmt *sql.Stmt
+	watchesDueStmt       *sql.Stmt
 )
 
 type Dao struct {
@@ -83,6 +292,9 @@ func New(cfg config.DBConfig) store.Dao {
 	if err != nil {
 		log.Fatal(err)
 	}
+	if err = migration.Up(db, migrations); err != nil {
+		log.Fatal(err)
+	}
 	bandInsertStmt, err = db.Prepare(bandInsert)
 	if err != nil {
 		log.Fatal(err)
@@ -91,7 +303,31 @@ func New(cfg config.DBConfig) store.Dao {
 	if err != nil {
 		log.Fatal(err)
 	}
-	eventsClearStmt, err = db.Prepare(eventsClear)
+	countryInsertStmt, err = db.Prepare(countryInsert)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cityCountryStmt, err = db.Prepare(cityCountryUpdate)
+	if err != nil {
+		log.Fatal(err)
+	}
+	venueInsertStmt, err = db.Prepare(venueInsert)
+	if err != nil {
+		log.Fatal(err)
+	}
+	genreInsertStmt, err = db.Prepare(genreInsert)
+	if err != nil {
+		log.Fatal(err)
+	}
+	bandGenresClearStmt, err = db.Prepare(bandGenresClear)
+	if err != nil {
+		log.Fatal(err)
+	}
+	bandGenreInsertStmt, err = db.Prepare(bandGenreInsert)
+	if err != nil {
+		log.Fatal(err)
+	}
+	eventsClearStmt, err = db.Prepare(eventsClearStale)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -103,6 +339,70 @@ func New(cfg config.DBConfig) store.Dao {
 	if err != nil {
 		log.Fatal(err)
 	}
+	bandAliasClearStmt, err = db.Prepare(bandAliasClear)
+	if err != nil {
+		log.Fatal(err)
+	}
+	bandAliasInsertStmt, err = db.Prepare(bandAliasInsert)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cityAliasClearStmt, err = db.Prepare(cityAliasClear)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cityAliasInsertStmt, err = db.Prepare(cityAliasInsert)
+	if err != nil {
+		log.Fatal(err)
+	}
+	bandsSimilarStmt, err = db.Prepare(bandsSimilar)
+	if err != nil {
+		log.Fatal(err)
+	}
+	citiesSimilarStmt, err = db.Prepare(citiesSimilar)
+	if err != nil {
+		log.Fatal(err)
+	}
+	bandsListStmt, err = db.Prepare(bandsList)
+	if err != nil {
+		log.Fatal(err)
+	}
+	citiesListStmt, err = db.Prepare(citiesList)
+	if err != nil {
+		log.Fatal(err)
+	}
+	subscriptionInsStmt, err = db.Prepare(subscriptionInsert)
+	if err != nil {
+		log.Fatal(err)
+	}
+	subscriptionDelStmt, err = db.Prepare(subscriptionDelete)
+	if err != nil {
+		log.Fatal(err)
+	}
+	subscriptionsUsrStmt, err = db.Prepare(subscriptionsOfUser)
+	if err != nil {
+		log.Fatal(err)
+	}
+	subscriptionsBndStmt, err = db.Prepare(subscriptionsOfBand)
+	if err != nil {
+		log.Fatal(err)
+	}
+	watchInsertStmt, err = db.Prepare(watchInsert)
+	if err != nil {
+		log.Fatal(err)
+	}
+	watchDeleteStmt, err = db.Prepare(watchDelete)
+	if err != nil {
+		log.Fatal(err)
+	}
+	watchesOfChannelStmt, err = db.Prepare(watchesOfChannel)
+	if err != nil {
+		log.Fatal(err)
+	}
+	watchesDueStmt, err = db.Prepare(watchesDue)
+	if err != nil {
+		log.Fatal(err)
+	}
 	return &Dao{
 		db,
 	}
@@ -111,21 +411,44 @@ func New(cfg config.DBConfig) store.Dao {
 func (d *Dao) Close() error {
 	bandInsertStmt.Close()
 	cityInsertStmt.Close()
+	countryInsertStmt.Close()
+	cityCountryStmt.Close()
+	venueInsertStmt.Close()
+	genreInsertStmt.Close()
+	bandGenresClearStmt.Close()
+	bandGenreInsertStmt.Close()
 	eventsClearStmt.Close()
 	eventsInsertStmt.Close()
 	eventsBandInCityStmt.Close()
+	bandAliasClearStmt.Close()
+	bandAliasInsertStmt.Close()
+	cityAliasClearStmt.Close()
+	cityAliasInsertStmt.Close()
+	bandsSimilarStmt.Close()
+	citiesSimilarStmt.Close()
+	bandsListStmt.Close()
+	citiesListStmt.Close()
+	subscriptionInsStmt.Close()
+	subscriptionDelStmt.Close()
+	subscriptionsUsrStmt.Close()
+	subscriptionsBndStmt.Close()
+	watchInsertStmt.Close()
+	watchDeleteStmt.Close()
+	watchesOfChannelStmt.Close()
+	watchesDueStmt.Close()
 	d.db.Close()
 	return nil
 }
 
-func (d *Dao) AddBandEvents(events []store.Event) error {
+func (d *Dao) AddBandEvents(events []store.Event) ([]store.Event, error) {
 	if len(events) == 0 {
-		return nil
+		return nil, nil
 	}
 	tx, err := d.db.Begin()
 	if err != nil {
-		return err
+		return nil, err
 	}
+	newEvents := make([]store.Event, 0)
 	if err = func() error {
 		var bandId int32
 		// add band if not exist
@@ -133,20 +456,109 @@ func (d *Dao) AddBandEvents(events []store.Event) error {
 		if err := tx.Stmt(bandInsertStmt).QueryRow(strings.ToLower(bandName), bandName).Scan(&bandId); err != nil {
 			return fmt.Errorf("insert band failed with %#v (band's name is %#v)\n", err, events[0].Band)
 		}
-		// clear previouse data
-		if _, err = tx.Stmt(eventsClearStmt).Exec(bandId); err != nil {
-			return fmt.Errorf("clear previouse band's events failed with %#v (band's id is %#v)\n", err, bandId)
+		// replace band's genres if they are known
+		if genres := events[0].Genres; len(genres) > 0 {
+			if _, err = tx.Stmt(bandGenresClearStmt).Exec(bandId); err != nil {
+				return fmt.Errorf("clear band's genres failed with %#v (band's id is %#v)\n", err, bandId)
+			}
+			for _, genre := range genres {
+				var genreId int32
+				if err := tx.Stmt(genreInsertStmt).QueryRow(strings.ToLower(genre), genre).Scan(&genreId); err != nil {
+					return fmt.Errorf("insert genre failed with %#v (genre is %#v)\n", err, genre)
+				}
+				if _, err = tx.Stmt(bandGenreInsertStmt).Exec(bandId, genreId); err != nil {
+					return fmt.Errorf("insert band's genre failed with %#v (genre is %#v)\n", err, genre)
+				}
+			}
 		}
+		ids := make([]string, 0, len(events))
 		for _, event := range events {
 			var cityId int32
 			// add city if not exist
 			if err := tx.Stmt(cityInsertStmt).QueryRow(strings.ToLower(event.City), event.City).Scan(&cityId); err != nil {
 				return fmt.Errorf("insert city failed with %#v (event is %#v)\n", err, event)
 			}
+			// link city with country if it's known
+			if event.Country != "" {
+				var countryId int32
+				if err := tx.Stmt(countryInsertStmt).QueryRow(strings.ToLower(event.Country), event.Country).Scan(&countryId); err != nil {
+					return fmt.Errorf("insert country failed with %#v (event is %#v)\n", err, event)
+				}
+				if _, err = tx.Stmt(cityCountryStmt).Exec(cityId, countryId); err != nil {
+					return fmt.Errorf("update city's country failed with %#v (event is %#v)\n", err, event)
+				}
+			}
+			// add venue if not exist
+			var venueId interface{} = nil
+			if event.Venue != "" {
+				var id int32
+				if err := tx.Stmt(venueInsertStmt).QueryRow(strings.ToLower(event.Venue), event.Venue, cityId, event.Address).Scan(&id); err != nil {
+					return fmt.Errorf("insert venue failed with %#v (event is %#v)\n", err, event)
+				}
+				venueId = id
+			}
 			// add event
-			if _, err = tx.Stmt(eventsInsertStmt).Exec(event.Title, event.From, event.To, bandId, cityId, event.Venue, event.Link, event.Img); err != nil {
+			var (
+				eventId int32
+				isNew   bool
+			)
+			if err := tx.Stmt(eventsInsertStmt).QueryRow(event.Title, event.From, event.To, bandId, cityId, venueId, event.Link, event.Img).Scan(&eventId, &isNew); err != nil {
 				return fmt.Errorf("insert band's event failed with %#v (event is %#v)\n", err, event)
 			}
+			if isNew {
+				newEvents = append(newEvents, event)
+			}
+			ids = append(ids, fmt.Sprint(eventId))
+		}
+		// clear events which are not actual
+		if _, err = tx.Stmt(eventsClearStmt).Exec(bandId, strings.Join(ids, ",")); err != nil {
+			return fmt.Errorf("clear previouse band's events failed with %#v (band's id is %#v)\n", err, bandId)
+		}
+		return nil
+	}(); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return newEvents, tx.Commit()
+}
+
+func (d *Dao) GetEvents(f store.Filter, offset, limit int) ([]store.Event, error) {
+	if offset < 0 {
+		return nil, store.ErrNegativeOffset
+	}
+	rows, err := eventsBandInCityStmt.Query(nullLower(f.Band), nullLower(f.City), nullLower(f.Country), nullLower(f.Venue), nullLower(f.Genre), f.From, f.To, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return d.rowsToEvents(rows)
+}
+
+func (d *Dao) AddBandAlias(band, alias string) error {
+	return d.addAlias(bandInsertStmt, bandAliasClearStmt, bandAliasInsertStmt, band, alias)
+}
+
+func (d *Dao) AddCityAlias(city, alias string) error {
+	return d.addAlias(cityInsertStmt, cityAliasClearStmt, cityAliasInsertStmt, city, alias)
+}
+
+// addAlias adds the row with the name if not exist and links the alias to it.
+// Previous link of the alias is removed.
+func (d *Dao) addAlias(insertStmt, clearStmt, aliasStmt *sql.Stmt, name, alias string) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err = func() error {
+		var id int32
+		if err := tx.Stmt(insertStmt).QueryRow(strings.ToLower(name), name).Scan(&id); err != nil {
+			return fmt.Errorf("insert %#v failed with %#v\n", name, err)
+		}
+		if _, err := tx.Stmt(clearStmt).Exec(strings.ToLower(alias)); err != nil {
+			return fmt.Errorf("clear alias %#v failed with %#v\n", alias, err)
+		}
+		if _, err := tx.Stmt(aliasStmt).Exec(alias, id); err != nil {
+			return fmt.Errorf("insert alias %#v failed with %#v\n", alias, err)
 		}
 		return nil
 	}(); err != nil {
@@ -156,45 +568,207 @@ func (d *Dao) AddBandEvents(events []store.Event) error {
 	return tx.Commit()
 }
 
-func (d *Dao) GetEvents(band string, city string, from, to int64, offset, limit int) ([]store.Event, error) {
-	var b interface{} = nil
-	var c interface{} = nil
-	if band != "" {
-		b = strings.ToLower(band)
+func (d *Dao) FindBands(name string, limit int) ([]store.Match, error) {
+	return d.findNames(bandsSimilarStmt, name, limit)
+}
+
+func (d *Dao) FindCities(name string, limit int) ([]store.Match, error) {
+	return d.findNames(citiesSimilarStmt, name, limit)
+}
+
+func (d *Dao) findNames(stmt *sql.Stmt, name string, limit int) ([]store.Match, error) {
+	rows, err := stmt.Query(name, store.MinSimilarity, limit)
+	if err != nil {
+		return nil, err
 	}
-	if city != "" {
-		c = strings.ToLower(city)
+	defer rows.Close()
+	return d.rowsToMatches(rows)
+}
+
+func (d *Dao) GetBands(offset, limit int) ([]string, error) {
+	return d.listNames(bandsListStmt, offset, limit)
+}
+
+func (d *Dao) GetCities(offset, limit int) ([]string, error) {
+	return d.listNames(citiesListStmt, offset, limit)
+}
+
+func (d *Dao) listNames(stmt *sql.Stmt, offset, limit int) ([]string, error) {
+	if offset < 0 {
+		return nil, store.ErrNegativeOffset
+	}
+	rows, err := stmt.Query(offset, limit)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
+	names := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
 
-	rows, err := eventsBandInCityStmt.Query(b, c, from, to, offset, limit)
+func (d *Dao) Follow(s store.Subscription) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err = func() error {
+		var bandId int32
+		if err := tx.Stmt(bandInsertStmt).QueryRow(strings.ToLower(s.Band), s.Band).Scan(&bandId); err != nil {
+			return fmt.Errorf("insert band failed with %#v (band's name is %#v)\n", err, s.Band)
+		}
+		if _, err := tx.Stmt(subscriptionInsStmt).Exec(s.User, s.Channel, bandId); err != nil {
+			return fmt.Errorf("insert subscription failed with %#v (subscription is %#v)\n", err, s)
+		}
+		return nil
+	}(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (d *Dao) Unfollow(s store.Subscription) error {
+	_, err := subscriptionDelStmt.Exec(s.User, s.Channel, strings.ToLower(s.Band))
+	return err
+}
+
+func (d *Dao) GetSubscriptions(user, channel string) ([]store.Subscription, error) {
+	rows, err := subscriptionsUsrStmt.Query(user, channel)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	return d.rowsToEvents(rows)
+	return d.rowsToSubscriptions(rows)
+}
+
+func (d *Dao) GetFollowers(band string) ([]store.Subscription, error) {
+	rows, err := subscriptionsBndStmt.Query(strings.ToLower(band))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return d.rowsToSubscriptions(rows)
+}
+
+func (d *Dao) AddWatch(w store.Watch) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err = func() error {
+		var cityId int32
+		if err := tx.Stmt(cityInsertStmt).QueryRow(strings.ToLower(w.City), w.City).Scan(&cityId); err != nil {
+			return fmt.Errorf("insert city failed with %#v (city's name is %#v)\n", err, w.City)
+		}
+		if _, err := tx.Stmt(watchInsertStmt).Exec(w.Channel, cityId, w.NextRun); err != nil {
+			return fmt.Errorf("insert watch failed with %#v (watch is %#v)\n", err, w)
+		}
+		return nil
+	}(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (d *Dao) RemoveWatch(channel, city string) error {
+	_, err := watchDeleteStmt.Exec(channel, strings.ToLower(city))
+	return err
+}
+
+func (d *Dao) GetWatches(channel string) ([]store.Watch, error) {
+	rows, err := watchesOfChannelStmt.Query(channel)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return d.rowsToWatches(rows)
+}
+
+func (d *Dao) GetDueWatches(at int64) ([]store.Watch, error) {
+	rows, err := watchesDueStmt.Query(at)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return d.rowsToWatches(rows)
 }
 
 func (d *Dao) rowsToEvents(rows *sql.Rows) ([]store.Event, error) {
 	events := make([]store.Event, 0)
 	for rows.Next() {
 		var (
-			title, bands, city string
-			venue, link, img   string
-			from, to           int64
+			title, bands, city, country string
+			venue, address, link, img   string
+			from, to                    int64
 		)
-		if err := rows.Scan(&title, &from, &to, &city, &venue, &link, &img, &bands); err != nil {
+		if err := rows.Scan(&title, &from, &to, &city, &country, &venue, &address, &link, &img, &bands); err != nil {
 			return nil, err
 		}
 		events = append(events, store.Event{
-			Band:  bands,
-			Title: title,
-			From:  from,
-			To:    to,
-			City:  city,
-			Venue: venue,
-			Link:  link,
-			Img:   img,
+			Band:    bands,
+			Title:   title,
+			From:    from,
+			To:      to,
+			City:    city,
+			Country: country,
+			Venue:   venue,
+			Address: address,
+			Link:    link,
+			Img:     img,
 		})
 	}
 	return events, rows.Err()
 }
+
+func (d *Dao) rowsToSubscriptions(rows *sql.Rows) ([]store.Subscription, error) {
+	subscriptions := make([]store.Subscription, 0)
+	for rows.Next() {
+		var s store.Subscription
+		if err := rows.Scan(&s.User, &s.Channel, &s.Band); err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, s)
+	}
+	return subscriptions, rows.Err()
+}
+
+func (d *Dao) rowsToWatches(rows *sql.Rows) ([]store.Watch, error) {
+	watches := make([]store.Watch, 0)
+	for rows.Next() {
+		var w store.Watch
+		if err := rows.Scan(&w.Channel, &w.City, &w.NextRun); err != nil {
+			return nil, err
+		}
+		watches = append(watches, w)
+	}
+	return watches, rows.Err()
+}
+
+func (d *Dao) rowsToMatches(rows *sql.Rows) ([]store.Match, error) {
+	matches := make([]store.Match, 0)
+	for rows.Next() {
+		var m store.Match
+		if err := rows.Scan(&m.Name, &m.Similarity); err != nil {
+			return nil, err
+		}
+		matches = append(matches, m)
+	}
+	return matches, rows.Err()
+}
+
+// nullLower returns the name in lower case or nil if the name is empty,
+// so it's not used to filter events.
+func nullLower(name string) interface{} {
+	if name == "" {
+		return nil
+	}
+	return strings.ToLower(name)
+}
@@ -0,0 +1,40 @@
+package pg
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/austinov/rocker-bot/config"
+	"github.com/austinov/rocker-bot/store"
+	"github.com/austinov/rocker-bot/store/storetest"
+)
+
+// testDSNEnv is a variable with connection string of test database,
+// the tests are skipped if it's not set. Data of the database is removed.
+const testDSNEnv = "ROCKER_TEST_PG"
+
+const truncateTables = `
+	TRUNCATE band, band_alias, band_genre, city, city_alias, country,
+	         event, genre, subscription, venue, watch RESTART IDENTITY CASCADE`
+
+func TestDao(t *testing.T) {
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skip(testDSNEnv + " is not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	storetest.Run(t, func() store.Dao {
+		// New applies migrations, so tables are cleared after it
+		dao := New(config.DBConfig{Type: "pg", ConnectionString: dsn})
+		if _, err := db.Exec(truncateTables); err != nil {
+			t.Fatal(err)
+		}
+		return dao
+	})
+}
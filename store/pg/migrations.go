@@ -0,0 +1,177 @@
+package pg
+
+import "github.com/austinov/rocker-bot/store/migration"
+
+// migrations of db schema, which are applied in New.
+// Never change the applied migration, add the new one instead.
+// The first one uses IF NOT EXISTS to adopt databases created
+// by scripts before migrations were introduced.
+var migrations = []migration.Migration{
+	{
+		Version: 1,
+		Script: `
+		CREATE TABLE IF NOT EXISTS band (
+		    "id"   serial primary key,
+		    "name" varchar(100) NOT NULL
+		);
+
+		CREATE INDEX IF NOT EXISTS ind_band_id ON band USING btree (id);
+		CREATE UNIQUE INDEX IF NOT EXISTS uni_band ON band (lower(name));
+
+		CREATE TABLE IF NOT EXISTS city (
+		    "id"   serial primary key,
+		    "name" varchar(100) NOT NULL
+		);
+
+		CREATE INDEX IF NOT EXISTS ind_city_id ON city USING btree (id);
+		CREATE UNIQUE INDEX IF NOT EXISTS uni_city ON city (lower(name));
+
+		CREATE TABLE IF NOT EXISTS event (
+		    "id"       serial primary key,
+		    "title"    varchar(255) NOT NULL,
+		    "begin_dt" bigint,
+		    "end_dt"   bigint,
+		    "band_id"  integer NOT NULL CONSTRAINT fk_event_band REFERENCES band (id),
+		    "city_id"  integer NOT NULL CONSTRAINT fk_event_city REFERENCES city (id),
+		    "venue"    varchar(255),
+		    "link"     varchar(255),
+		    "img"      varchar(255)
+		);
+
+		CREATE INDEX IF NOT EXISTS ind_event_id ON event USING btree (id);
+		CREATE INDEX IF NOT EXISTS ind_event_title ON event USING btree (title);
+		CREATE INDEX IF NOT EXISTS ind_event_begin ON event USING btree (begin_dt);
+		CREATE INDEX IF NOT EXISTS ind_event_end ON event USING btree (end_dt);
+		CREATE INDEX IF NOT EXISTS ind_event_band ON event USING btree (band_id);
+		CREATE INDEX IF NOT EXISTS ind_event_city ON event USING btree (city_id);
+		CREATE UNIQUE INDEX IF NOT EXISTS uni_event ON event (title, begin_dt, end_dt, band_id, city_id);
+
+		CREATE OR REPLACE VIEW vw_events AS
+		    SELECT e.*, c.name AS city_name, b.name AS band_name
+		    FROM event e
+		        JOIN city c ON e.city_id = c.id
+		        JOIN band b ON e.band_id = b.id;`,
+	},
+	{
+		Version: 2,
+		Script: `
+		CREATE EXTENSION IF NOT EXISTS pg_trgm;
+		CREATE EXTENSION IF NOT EXISTS unaccent;`,
+	},
+	{
+		Version: 3,
+		Script: `
+		CREATE TABLE band_alias (
+		    "alias"   varchar(100) NOT NULL,
+		    "band_id" integer NOT NULL CONSTRAINT fk_band_alias_band REFERENCES band (id)
+		);
+
+		CREATE UNIQUE INDEX uni_band_alias ON band_alias (lower(alias));
+
+		CREATE TABLE city_alias (
+		    "alias"   varchar(100) NOT NULL,
+		    "city_id" integer NOT NULL CONSTRAINT fk_city_alias_city REFERENCES city (id)
+		);
+
+		CREATE UNIQUE INDEX uni_city_alias ON city_alias (lower(alias));`,
+	},
+	{
+		Version: 4,
+		Script: `
+		CREATE TABLE country (
+		    "id"   serial primary key,
+		    "name" varchar(100) NOT NULL
+		);
+
+		CREATE UNIQUE INDEX uni_country ON country (lower(name));
+
+		ALTER TABLE city ADD COLUMN "country_id" integer CONSTRAINT fk_city_country REFERENCES country (id);
+
+		CREATE OR REPLACE VIEW vw_events AS
+		    SELECT e.*, c.name AS city_name, b.name AS band_name, co.name AS country_name
+		    FROM event e
+		        JOIN city c ON e.city_id = c.id
+		        JOIN band b ON e.band_id = b.id
+		        LEFT JOIN country co ON c.country_id = co.id;`,
+	},
+	{
+		Version: 5,
+		Script: `
+		CREATE TABLE venue (
+		    "id"      serial primary key,
+		    "name"    varchar(255) NOT NULL,
+		    "city_id" integer NOT NULL CONSTRAINT fk_venue_city REFERENCES city (id),
+		    "address" varchar(255)
+		);
+
+		CREATE UNIQUE INDEX uni_venue ON venue (lower(name), city_id);
+
+		ALTER TABLE event ADD COLUMN "venue_id" integer CONSTRAINT fk_event_venue REFERENCES venue (id);
+		CREATE INDEX ind_event_venue ON event USING btree (venue_id);
+
+		INSERT INTO venue (name, city_id)
+		    SELECT DISTINCT ON (lower(venue), city_id) venue, city_id
+		    FROM event
+		    WHERE venue <> '';
+
+		UPDATE event e
+		SET venue_id = v.id
+		FROM venue v
+		WHERE lower(e.venue) = lower(v.name) AND e.city_id = v.city_id;
+
+		DROP VIEW vw_events;
+
+		ALTER TABLE event DROP COLUMN venue;
+
+		CREATE VIEW vw_events AS
+		    SELECT e.*, c.name AS city_name, b.name AS band_name, co.name AS country_name,
+		           v.name AS venue_name, v.address AS venue_address
+		    FROM event e
+		        JOIN city c ON e.city_id = c.id
+		        JOIN band b ON e.band_id = b.id
+		        LEFT JOIN country co ON c.country_id = co.id
+		        LEFT JOIN venue v ON e.venue_id = v.id;`,
+	},
+	{
+		Version: 6,
+		Script: `
+		CREATE TABLE genre (
+		    "id"   serial primary key,
+		    "name" varchar(100) NOT NULL
+		);
+
+		CREATE UNIQUE INDEX uni_genre ON genre (lower(name));
+
+		CREATE TABLE band_genre (
+		    "band_id"  integer NOT NULL CONSTRAINT fk_band_genre_band REFERENCES band (id),
+		    "genre_id" integer NOT NULL CONSTRAINT fk_band_genre_genre REFERENCES genre (id),
+		    PRIMARY KEY (band_id, genre_id)
+		);
+
+		CREATE INDEX ind_band_genre_genre ON band_genre USING btree (genre_id);`,
+	},
+	{
+		Version: 7,
+		Script: `
+		CREATE TABLE subscription (
+		    "user_id"    varchar(50) NOT NULL,
+		    "channel_id" varchar(50) NOT NULL,
+		    "band_id"    integer NOT NULL CONSTRAINT fk_subscription_band REFERENCES band (id),
+		    PRIMARY KEY (user_id, channel_id, band_id)
+		);
+
+		CREATE INDEX ind_subscription_band ON subscription USING btree (band_id);`,
+	},
+	{
+		Version: 8,
+		Script: `
+		CREATE TABLE watch (
+		    "channel_id" varchar(50) NOT NULL,
+		    "city_id"    integer NOT NULL CONSTRAINT fk_watch_city REFERENCES city (id),
+		    "next_run"   bigint NOT NULL,
+		    PRIMARY KEY (channel_id, city_id)
+		);
+
+		CREATE INDEX ind_watch_next_run ON watch USING btree (next_run);`,
+	},
+}
@@ -0,0 +1,742 @@
+package sqlite
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"database/sql"
+
+	"github.com/austinov/rocker-bot/common"
+	"github.com/austinov/rocker-bot/config"
+	"github.com/austinov/rocker-bot/store"
+	"github.com/austinov/rocker-bot/store/migration"
+	sqlite3 "github.com/mattn/go-sqlite3"
+)
+
+const driverName = "sqlite3_rocker"
+
+const (
+	bandSelect = `
+	    SELECT id FROM band WHERE fold_lower(name) = ?1
+	    UNION ALL
+	    SELECT band_id FROM band_alias WHERE fold_lower(alias) = ?1
+	    LIMIT 1`
+
+	bandInsert = `
+	    INSERT INTO band (name)
+	    VALUES (?1)`
+
+	citySelect = `
+	    SELECT id FROM city WHERE fold_lower(name) = ?1
+	    UNION ALL
+	    SELECT city_id FROM city_alias WHERE fold_lower(alias) = ?1
+	    LIMIT 1`
+
+	cityInsert = `
+	    INSERT INTO city (name)
+	    VALUES (?1)`
+
+	countrySelect = `
+	    SELECT id
+	    FROM country
+	    WHERE fold_lower(name) = ?1`
+
+	countryInsert = `
+	    INSERT INTO country (name)
+	    VALUES (?1)`
+
+	cityCountryUpdate = `
+	    UPDATE city
+	    SET country_id = ?2
+	    WHERE id = ?1`
+
+	venueSelect = `
+	    SELECT id
+	    FROM venue
+	    WHERE fold_lower(name) = ?1 AND city_id = ?2`
+
+	venueInsert = `
+	    INSERT INTO venue (name, city_id, address)
+	    VALUES (?1, ?2, ?3)`
+
+	venueAddressUpdate = `
+	    UPDATE venue
+	    SET address = ?2
+	    WHERE id = ?1`
+
+	genreSelect = `
+	    SELECT id
+	    FROM genre
+	    WHERE fold_lower(name) = ?1`
+
+	genreInsert = `
+	    INSERT INTO genre (name)
+	    VALUES (?1)`
+
+	bandGenresClear = `
+	    DELETE FROM band_genre
+	    WHERE band_id = ?1`
+
+	bandGenreInsert = `
+	    INSERT OR IGNORE INTO band_genre (band_id, genre_id)
+	    VALUES (?1, ?2)`
+
+	// eventsClearStale removes band's events which ids are not in
+	// the comma separated list of ids.
+	eventsClearStale = `
+	    DELETE FROM event
+	    WHERE band_id = ?1 AND instr(',' || ?2 || ',', ',' || id || ',') = 0`
+
+	eventSelect = `
+	    SELECT id
+	    FROM event
+	    WHERE title = ?1 AND begin_dt = ?2 AND end_dt = ?3 AND band_id = ?4 AND city_id = ?5`
+
+	eventInsert = `
+	    INSERT INTO event(title, begin_dt, end_dt, band_id, city_id, venue_id, link, img)
+	    VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8)`
+
+	eventUpdate = `
+	    UPDATE event
+	    SET venue_id = ?2, link = ?3, img = ?4
+	    WHERE id = ?1`
+
+	// SQLite has no string_agg(DISTINCT ..., ', '), so distinct and
+	// ordered band's names are selected in subquery before grouping.
+	eventsBandInCity = `
+	    SELECT title, begin_dt, end_dt, city_name, country_name, venue_name, venue_address, link, img, group_concat(band_name, ', ') AS bands
+	    FROM (
+	        SELECT DISTINCT title, begin_dt, end_dt, city_name, COALESCE(country_name, '') AS country_name,
+	               COALESCE(venue_name, '') AS venue_name, COALESCE(venue_address, '') AS venue_address, link, img, band_name
+	        FROM vw_events
+	        WHERE (?1 IS NULL OR band_id IN (
+	                  SELECT id FROM band WHERE fold_lower(name) = ?1
+	                  UNION
+	                  SELECT band_id FROM band_alias WHERE fold_lower(alias) = ?1)) AND
+	              (?2 IS NULL OR city_id IN (
+	                  SELECT id FROM city WHERE fold_lower(name) = ?2
+	                  UNION
+	                  SELECT city_id FROM city_alias WHERE fold_lower(alias) = ?2)) AND
+	              (?3 IS NULL OR fold_lower(COALESCE(country_name, '')) = ?3) AND
+	              (?4 IS NULL OR fold_lower(COALESCE(venue_name, '')) = ?4) AND
+	              (?5 IS NULL OR band_id IN (
+	                  SELECT bg.band_id
+	                  FROM band_genre bg
+	                      JOIN genre g ON bg.genre_id = g.id
+	                  WHERE instr(fold_lower(g.name), ?5) > 0)) AND
+	              begin_dt >= ?6 AND (?7 = 0 OR end_dt <= ?7)
+	        ORDER BY band_name
+	    )
+	    GROUP BY title, begin_dt, end_dt, city_name, country_name, venue_name, venue_address, link, img
+	    ORDER BY begin_dt LIMIT ?9 OFFSET ?8`
+
+	bandAliasInsert = `
+	    INSERT OR REPLACE INTO band_alias(alias, band_id)
+	    VALUES (?1, ?2)`
+
+	cityAliasInsert = `
+	    INSERT OR REPLACE INTO city_alias(alias, city_id)
+	    VALUES (?1, ?2)`
+
+	bandsSimilar = `
+	    SELECT name, similarity(name, ?1) AS sml
+	    FROM band
+	    WHERE similarity(name, ?1) >= ?2
+	    ORDER BY sml DESC, name LIMIT ?3`
+
+	citiesSimilar = `
+	    SELECT name, similarity(name, ?1) AS sml
+	    FROM city
+	    WHERE similarity(name, ?1) >= ?2
+	    ORDER BY sml DESC, name LIMIT ?3`
+
+	bandsList = `
+	    SELECT name
+	    FROM band
+	    ORDER BY fold_lower(name) LIMIT ?2 OFFSET ?1`
+
+	citiesList = `
+	    SELECT name
+	    FROM city
+	    ORDER BY fold_lower(name) LIMIT ?2 OFFSET ?1`
+
+	subscriptionInsert = `
+	    INSERT OR IGNORE INTO subscription (user_id, channel_id, band_id)
+	    VALUES (?1, ?2, ?3)`
+
+	subscriptionDelete = `
+	    DELETE FROM subscription
+	    WHERE user_id = ?1 AND channel_id = ?2 AND band_id IN (
+	        SELECT id FROM band WHERE fold_lower(name) = ?3
+	        UNION
+	        SELECT band_id FROM band_alias WHERE fold_lower(alias) = ?3)`
+
+	subscriptionsOfUser = `
+	    SELECT s.user_id, s.channel_id, b.name
+	    FROM subscription s
+	        JOIN band b ON s.band_id = b.id
+	    WHERE s.user_id = ?1 AND s.channel_id = ?2
+	    ORDER BY b.name`
+
+	subscriptionsOfBand = `
+	    SELECT s.user_id, s.channel_id, b.name
+	    FROM subscription s
+	        JOIN band b ON s.band_id = b.id
+	    WHERE s.band_id IN (
+	        SELECT id FROM band WHERE fold_lower(name) = ?1
+	        UNION
+	        SELECT band_id FROM band_alias WHERE fold_lower(alias) = ?1)
+	    ORDER BY s.channel_id, s.user_id`
+
+	watchInsert = `
+	    INSERT OR REPLACE INTO watch (channel_id, city_id, next_run)
+	    VALUES (?1, ?2, ?3)`
+
+	watchDelete = `
+	    DELETE FROM watch
+	    WHERE channel_id = ?1 AND city_id IN (
+	        SELECT id FROM city WHERE fold_lower(name) = ?2
+	        UNION
+	        SELECT city_id FROM city_alias WHERE fold_lower(alias) = ?2)`
+
+	watchesOfChannel = `
+	    SELECT w.channel_id, c.name, w.next_run
+	    FROM watch w
+	        JOIN city c ON w.city_id = c.id
+	    WHERE w.channel_id = ?1
+	    ORDER BY c.name`
+
+	watchesDue = `
+	    SELECT w.channel_id, c.name, w.next_run
+	    FROM watch w
+	        JOIN city c ON w.city_id = c.id
+	    WHERE w.next_run <= ?1
+	    ORDER BY w.next_run, w.channel_id, c.name`
+)
+
+func init() {
+	// SQLite's lower() handles ASCII only, so names are compared by
+	// fold_lower() in the same way as PostgreSQL does. The builtin lower()
+	// isn't replaced to keep the db usable by other tools, e.g. sqlite3.
+	// SQLite has no pg_trgm, so similarity() is implemented in Go.
+	sql.Register(driverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			if err := conn.RegisterFunc("fold_lower", strings.ToLower, true); err != nil {
+				return err
+			}
+			return conn.RegisterFunc("similarity", common.Similarity, true)
+		},
+	})
+}
+
+type Dao struct {
+	db                   *sql.DB
+	bandSelectStmt       *sql.Stmt
+	bandInsertStmt       *sql.Stmt
+	citySelectStmt       *sql.Stmt
+	cityInsertStmt       *sql.Stmt
+	countrySelectStmt    *sql.Stmt
+	countryInsertStmt    *sql.Stmt
+	cityCountryStmt      *sql.Stmt
+	venueSelectStmt      *sql.Stmt
+	venueInsertStmt      *sql.Stmt
+	venueAddressStmt     *sql.Stmt
+	genreSelectStmt      *sql.Stmt
+	genreInsertStmt      *sql.Stmt
+	bandGenresClearStmt  *sql.Stmt
+	bandGenreInsertStmt  *sql.Stmt
+	eventsClearStmt      *sql.Stmt
+	eventSelectStmt      *sql.Stmt
+	eventsInsertStmt     *sql.Stmt
+	eventUpdateStmt      *sql.Stmt
+	eventsBandInCityStmt *sql.Stmt
+	bandAliasInsertStmt  *sql.Stmt
+	cityAliasInsertStmt  *sql.Stmt
+	bandsSimilarStmt     *sql.Stmt
+	citiesSimilarStmt    *sql.Stmt
+	bandsListStmt        *sql.Stmt
+	citiesListStmt       *sql.Stmt
+	subscriptionInsStmt  *sql.Stmt
+	subscriptionDelStmt  *sql.Stmt
+	subscriptionsUsrStmt *sql.Stmt
+	subscriptionsBndStmt *sql.Stmt
+	watchInsertStmt      *sql.Stmt
+	watchDeleteStmt      *sql.Stmt
+	watchesOfChannelStmt *sql.Stmt
+	watchesDueStmt       *sql.Stmt
+}
+
+func New(cfg config.DBConfig) store.Dao {
+	db, err := sql.Open(driverName, cfg.ConnectionString)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// SQLite allows only one writer at time
+	db.SetMaxOpenConns(1)
+
+	if err = migration.Up(db, migrations); err != nil {
+		log.Fatal(err)
+	}
+	d := &Dao{
+		db: db,
+	}
+	d.bandSelectStmt = prepare(db, bandSelect)
+	d.bandInsertStmt = prepare(db, bandInsert)
+	d.citySelectStmt = prepare(db, citySelect)
+	d.cityInsertStmt = prepare(db, cityInsert)
+	d.countrySelectStmt = prepare(db, countrySelect)
+	d.countryInsertStmt = prepare(db, countryInsert)
+	d.cityCountryStmt = prepare(db, cityCountryUpdate)
+	d.venueSelectStmt = prepare(db, venueSelect)
+	d.venueInsertStmt = prepare(db, venueInsert)
+	d.venueAddressStmt = prepare(db, venueAddressUpdate)
+	d.genreSelectStmt = prepare(db, genreSelect)
+	d.genreInsertStmt = prepare(db, genreInsert)
+	d.bandGenresClearStmt = prepare(db, bandGenresClear)
+	d.bandGenreInsertStmt = prepare(db, bandGenreInsert)
+	d.eventsClearStmt = prepare(db, eventsClearStale)
+	d.eventSelectStmt = prepare(db, eventSelect)
+	d.eventsInsertStmt = prepare(db, eventInsert)
+	d.eventUpdateStmt = prepare(db, eventUpdate)
+	d.eventsBandInCityStmt = prepare(db, eventsBandInCity)
+	d.bandAliasInsertStmt = prepare(db, bandAliasInsert)
+	d.cityAliasInsertStmt = prepare(db, cityAliasInsert)
+	d.bandsSimilarStmt = prepare(db, bandsSimilar)
+	d.citiesSimilarStmt = prepare(db, citiesSimilar)
+	d.bandsListStmt = prepare(db, bandsList)
+	d.citiesListStmt = prepare(db, citiesList)
+	d.subscriptionInsStmt = prepare(db, subscriptionInsert)
+	d.subscriptionDelStmt = prepare(db, subscriptionDelete)
+	d.subscriptionsUsrStmt = prepare(db, subscriptionsOfUser)
+	d.subscriptionsBndStmt = prepare(db, subscriptionsOfBand)
+	d.watchInsertStmt = prepare(db, watchInsert)
+	d.watchDeleteStmt = prepare(db, watchDelete)
+	d.watchesOfChannelStmt = prepare(db, watchesOfChannel)
+	d.watchesDueStmt = prepare(db, watchesDue)
+	return d
+}
+
+func prepare(db *sql.DB, query string) *sql.Stmt {
+	stmt, err := db.Prepare(query)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return stmt
+}
+
+func (d *Dao) Close() error {
+	d.bandSelectStmt.Close()
+	d.bandInsertStmt.Close()
+	d.citySelectStmt.Close()
+	d.cityInsertStmt.Close()
+	d.countrySelectStmt.Close()
+	d.countryInsertStmt.Close()
+	d.cityCountryStmt.Close()
+	d.venueSelectStmt.Close()
+	d.venueInsertStmt.Close()
+	d.venueAddressStmt.Close()
+	d.genreSelectStmt.Close()
+	d.genreInsertStmt.Close()
+	d.bandGenresClearStmt.Close()
+	d.bandGenreInsertStmt.Close()
+	d.eventsClearStmt.Close()
+	d.eventSelectStmt.Close()
+	d.eventsInsertStmt.Close()
+	d.eventUpdateStmt.Close()
+	d.eventsBandInCityStmt.Close()
+	d.bandAliasInsertStmt.Close()
+	d.cityAliasInsertStmt.Close()
+	d.bandsSimilarStmt.Close()
+	d.citiesSimilarStmt.Close()
+	d.bandsListStmt.Close()
+	d.citiesListStmt.Close()
+	d.subscriptionInsStmt.Close()
+	d.subscriptionDelStmt.Close()
+	d.subscriptionsUsrStmt.Close()
+	d.subscriptionsBndStmt.Close()
+	d.watchInsertStmt.Close()
+	d.watchDeleteStmt.Close()
+	d.watchesOfChannelStmt.Close()
+	d.watchesDueStmt.Close()
+	d.db.Close()
+	return nil
+}
+
+func (d *Dao) AddBandEvents(events []store.Event) ([]store.Event, error) {
+	if len(events) == 0 {
+		return nil, nil
+	}
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	newEvents := make([]store.Event, 0)
+	if err = func() error {
+		// add band if not exist
+		bandName := events[0].Band
+		bandId, err := upsert(tx.Stmt(d.bandSelectStmt), tx.Stmt(d.bandInsertStmt), bandName)
+		if err != nil {
+			return fmt.Errorf("insert band failed with %#v (band's name is %#v)\n", err, events[0].Band)
+		}
+		// replace band's genres if they are known
+		if genres := events[0].Genres; len(genres) > 0 {
+			if _, err = tx.Stmt(d.bandGenresClearStmt).Exec(bandId); err != nil {
+				return fmt.Errorf("clear band's genres failed with %#v (band's id is %#v)\n", err, bandId)
+			}
+			for _, genre := range genres {
+				genreId, err := upsert(tx.Stmt(d.genreSelectStmt), tx.Stmt(d.genreInsertStmt), genre)
+				if err != nil {
+					return fmt.Errorf("insert genre failed with %#v (genre is %#v)\n", err, genre)
+				}
+				if _, err = tx.Stmt(d.bandGenreInsertStmt).Exec(bandId, genreId); err != nil {
+					return fmt.Errorf("insert band's genre failed with %#v (genre is %#v)\n", err, genre)
+				}
+			}
+		}
+		ids := make([]string, 0, len(events))
+		for _, event := range events {
+			// add city if not exist
+			cityId, err := upsert(tx.Stmt(d.citySelectStmt), tx.Stmt(d.cityInsertStmt), event.City)
+			if err != nil {
+				return fmt.Errorf("insert city failed with %#v (event is %#v)\n", err, event)
+			}
+			// link city with country if it's known
+			if event.Country != "" {
+				countryId, err := upsert(tx.Stmt(d.countrySelectStmt), tx.Stmt(d.countryInsertStmt), event.Country)
+				if err != nil {
+					return fmt.Errorf("insert country failed with %#v (event is %#v)\n", err, event)
+				}
+				if _, err = tx.Stmt(d.cityCountryStmt).Exec(cityId, countryId); err != nil {
+					return fmt.Errorf("update city's country failed with %#v (event is %#v)\n", err, event)
+				}
+			}
+			// add venue if not exist
+			var venueId interface{} = nil
+			if event.Venue != "" {
+				if venueId, err = d.upsertVenue(tx, event, cityId); err != nil {
+					return fmt.Errorf("insert venue failed with %#v (event is %#v)\n", err, event)
+				}
+			}
+			// add event
+			eventId, isNew, err := d.upsertEvent(tx, event, bandId, cityId, venueId)
+			if err != nil {
+				return fmt.Errorf("insert band's event failed with %#v (event is %#v)\n", err, event)
+			}
+			if isNew {
+				newEvents = append(newEvents, event)
+			}
+			ids = append(ids, fmt.Sprint(eventId))
+		}
+		// clear events which are not actual
+		if _, err = tx.Stmt(d.eventsClearStmt).Exec(bandId, strings.Join(ids, ",")); err != nil {
+			return fmt.Errorf("clear previouse band's events failed with %#v (band's id is %#v)\n", err, bandId)
+		}
+		return nil
+	}(); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return newEvents, tx.Commit()
+}
+
+// upsertEvent returns id of band's event, it inserts new event if it does
+// not exist or updates the existing one. It reports whether event is new.
+func (d *Dao) upsertEvent(tx *sql.Tx, event store.Event, bandId, cityId int64, venueId interface{}) (int64, bool, error) {
+	var id int64
+	err := tx.Stmt(d.eventSelectStmt).QueryRow(event.Title, event.From, event.To, bandId, cityId).Scan(&id)
+	if err == sql.ErrNoRows {
+		res, err := tx.Stmt(d.eventsInsertStmt).Exec(event.Title, event.From, event.To, bandId, cityId, venueId, event.Link, event.Img)
+		if err != nil {
+			return 0, false, err
+		}
+		id, err = res.LastInsertId()
+		return id, true, err
+	}
+	if err == nil {
+		_, err = tx.Stmt(d.eventUpdateStmt).Exec(id, venueId, event.Link, event.Img)
+	}
+	return id, false, err
+}
+
+// upsertVenue returns id of event's venue in the city, it inserts new venue
+// if it does not exist or updates the address of existing one.
+func (d *Dao) upsertVenue(tx *sql.Tx, event store.Event, cityId int64) (int64, error) {
+	var id int64
+	err := tx.Stmt(d.venueSelectStmt).QueryRow(strings.ToLower(event.Venue), cityId).Scan(&id)
+	if err == sql.ErrNoRows {
+		res, err := tx.Stmt(d.venueInsertStmt).Exec(event.Venue, cityId, event.Address)
+		if err != nil {
+			return 0, err
+		}
+		return res.LastInsertId()
+	}
+	if err == nil && event.Address != "" {
+		_, err = tx.Stmt(d.venueAddressStmt).Exec(id, event.Address)
+	}
+	return id, err
+}
+
+// upsert returns id of row with the name (case insensitive)
+// and inserts new row if it does not exist.
+func upsert(selectStmt, insertStmt *sql.Stmt, name string) (int64, error) {
+	var id int64
+	err := selectStmt.QueryRow(strings.ToLower(name)).Scan(&id)
+	if err == sql.ErrNoRows {
+		res, err := insertStmt.Exec(name)
+		if err != nil {
+			return 0, err
+		}
+		return res.LastInsertId()
+	}
+	return id, err
+}
+
+func (d *Dao) GetEvents(f store.Filter, offset, limit int) ([]store.Event, error) {
+	// SQLite treats negative offset as zero, so it's checked here
+	if offset < 0 {
+		return nil, store.ErrNegativeOffset
+	}
+	rows, err := d.eventsBandInCityStmt.Query(nullLower(f.Band), nullLower(f.City), nullLower(f.Country), nullLower(f.Venue), nullLower(f.Genre), f.From, f.To, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return d.rowsToEvents(rows)
+}
+
+func (d *Dao) AddBandAlias(band, alias string) error {
+	return d.addAlias(d.bandSelectStmt, d.bandInsertStmt, d.bandAliasInsertStmt, band, alias)
+}
+
+func (d *Dao) AddCityAlias(city, alias string) error {
+	return d.addAlias(d.citySelectStmt, d.cityInsertStmt, d.cityAliasInsertStmt, city, alias)
+}
+
+// addAlias adds the row with the name if not exist and links the alias to it.
+// Previous link of the alias is replaced.
+func (d *Dao) addAlias(selectStmt, insertStmt, aliasStmt *sql.Stmt, name, alias string) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err = func() error {
+		id, err := upsert(tx.Stmt(selectStmt), tx.Stmt(insertStmt), name)
+		if err != nil {
+			return fmt.Errorf("insert %#v failed with %#v\n", name, err)
+		}
+		if _, err := tx.Stmt(aliasStmt).Exec(alias, id); err != nil {
+			return fmt.Errorf("insert alias %#v failed with %#v\n", alias, err)
+		}
+		return nil
+	}(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (d *Dao) FindBands(name string, limit int) ([]store.Match, error) {
+	return findNames(d.bandsSimilarStmt, name, limit)
+}
+
+func (d *Dao) FindCities(name string, limit int) ([]store.Match, error) {
+	return findNames(d.citiesSimilarStmt, name, limit)
+}
+
+func findNames(stmt *sql.Stmt, name string, limit int) ([]store.Match, error) {
+	rows, err := stmt.Query(name, store.MinSimilarity, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return rowsToMatches(rows)
+}
+
+func (d *Dao) GetBands(offset, limit int) ([]string, error) {
+	return listNames(d.bandsListStmt, offset, limit)
+}
+
+func (d *Dao) GetCities(offset, limit int) ([]string, error) {
+	return listNames(d.citiesListStmt, offset, limit)
+}
+
+func listNames(stmt *sql.Stmt, offset, limit int) ([]string, error) {
+	if offset < 0 {
+		return nil, store.ErrNegativeOffset
+	}
+	rows, err := stmt.Query(offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	names := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+func (d *Dao) Follow(s store.Subscription) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err = func() error {
+		bandId, err := upsert(tx.Stmt(d.bandSelectStmt), tx.Stmt(d.bandInsertStmt), s.Band)
+		if err != nil {
+			return fmt.Errorf("insert band failed with %#v (band's name is %#v)\n", err, s.Band)
+		}
+		if _, err := tx.Stmt(d.subscriptionInsStmt).Exec(s.User, s.Channel, bandId); err != nil {
+			return fmt.Errorf("insert subscription failed with %#v (subscription is %#v)\n", err, s)
+		}
+		return nil
+	}(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (d *Dao) Unfollow(s store.Subscription) error {
+	_, err := d.subscriptionDelStmt.Exec(s.User, s.Channel, strings.ToLower(s.Band))
+	return err
+}
+
+func (d *Dao) GetSubscriptions(user, channel string) ([]store.Subscription, error) {
+	rows, err := d.subscriptionsUsrStmt.Query(user, channel)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return rowsToSubscriptions(rows)
+}
+
+func (d *Dao) GetFollowers(band string) ([]store.Subscription, error) {
+	rows, err := d.subscriptionsBndStmt.Query(strings.ToLower(band))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return rowsToSubscriptions(rows)
+}
+
+func (d *Dao) AddWatch(w store.Watch) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err = func() error {
+		cityId, err := upsert(tx.Stmt(d.citySelectStmt), tx.Stmt(d.cityInsertStmt), w.City)
+		if err != nil {
+			return fmt.Errorf("insert city failed with %#v (city's name is %#v)\n", err, w.City)
+		}
+		if _, err := tx.Stmt(d.watchInsertStmt).Exec(w.Channel, cityId, w.NextRun); err != nil {
+			return fmt.Errorf("insert watch failed with %#v (watch is %#v)\n", err, w)
+		}
+		return nil
+	}(); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (d *Dao) RemoveWatch(channel, city string) error {
+	_, err := d.watchDeleteStmt.Exec(channel, strings.ToLower(city))
+	return err
+}
+
+func (d *Dao) GetWatches(channel string) ([]store.Watch, error) {
+	rows, err := d.watchesOfChannelStmt.Query(channel)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return rowsToWatches(rows)
+}
+
+func (d *Dao) GetDueWatches(at int64) ([]store.Watch, error) {
+	rows, err := d.watchesDueStmt.Query(at)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return rowsToWatches(rows)
+}
+
+func (d *Dao) rowsToEvents(rows *sql.Rows) ([]store.Event, error) {
+	events := make([]store.Event, 0)
+	for rows.Next() {
+		var (
+			title, bands, city, country string
+			venue, address, link, img   string
+			from, to                    int64
+		)
+		if err := rows.Scan(&title, &from, &to, &city, &country, &venue, &address, &link, &img, &bands); err != nil {
+			return nil, err
+		}
+		events = append(events, store.Event{
+			Band:    bands,
+			Title:   title,
+			From:    from,
+			To:      to,
+			City:    city,
+			Country: country,
+			Venue:   venue,
+			Address: address,
+			Link:    link,
+			Img:     img,
+		})
+	}
+	return events, rows.Err()
+}
+
+func rowsToSubscriptions(rows *sql.Rows) ([]store.Subscription, error) {
+	subscriptions := make([]store.Subscription, 0)
+	for rows.Next() {
+		var s store.Subscription
+		if err := rows.Scan(&s.User, &s.Channel, &s.Band); err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, s)
+	}
+	return subscriptions, rows.Err()
+}
+
+func rowsToWatches(rows *sql.Rows) ([]store.Watch, error) {
+	watches := make([]store.Watch, 0)
+	for rows.Next() {
+		var w store.Watch
+		if err := rows.Scan(&w.Channel, &w.City, &w.NextRun); err != nil {
+			return nil, err
+		}
+		watches = append(watches, w)
+	}
+	return watches, rows.Err()
+}
+
+func rowsToMatches(rows *sql.Rows) ([]store.Match, error) {
+	matches := make([]store.Match, 0)
+	for rows.Next() {
+		var m store.Match
+		if err := rows.Scan(&m.Name, &m.Similarity); err != nil {
+			return nil, err
+		}
+		matches = append(matches, m)
+	}
+	return matches, rows.Err()
+}
+
+// nullLower returns the name in lower case or nil if the name is empty,
+// so it's not used to filter events.
+func nullLower(name string) interface{} {
+	if name == "" {
+		return nil
+	}
+	return strings.ToLower(name)
+}
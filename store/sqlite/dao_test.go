@@ -0,0 +1,36 @@
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/austinov/rocker-bot/config"
+	"github.com/austinov/rocker-bot/store"
+	"github.com/austinov/rocker-bot/store/storetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDao(t *testing.T) {
+	storetest.Run(t, func() store.Dao {
+		return New(config.DBConfig{Type: "sqlite", ConnectionString: ":memory:"})
+	})
+}
+
+func TestFoldLower(t *testing.T) {
+	dao := New(config.DBConfig{Type: "sqlite", ConnectionString: ":memory:"}).(*Dao)
+	defer dao.Close()
+	_, err := dao.AddBandEvents([]store.Event{
+		{Band: "Mötley Crüe", Title: "Mötley Crüe", From: 10, To: 10, City: "Zürich"},
+	})
+	assert.NoError(t, err)
+
+	// names are compared in lower case not only for ASCII
+	events, err := dao.GetEvents(store.Filter{Band: "MÖTLEY CRÜE", City: "ZÜRICH"}, 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+
+	// builtin lower() isn't replaced
+	var lower, foldLower string
+	assert.NoError(t, dao.db.QueryRow("SELECT lower('ÜA'), fold_lower('ÜA')").Scan(&lower, &foldLower))
+	assert.Equal(t, "Üa", lower)
+	assert.Equal(t, "üa", foldLower)
+}
@@ -0,0 +1,195 @@
+package sqlite
+
+import "github.com/austinov/rocker-bot/store/migration"
+
+// migrations of db schema, which are applied in New.
+// Never change the applied migration, add the new one instead.
+var migrations = []migration.Migration{
+	{
+		Version: 1,
+		Script: `
+		CREATE TABLE IF NOT EXISTS band (
+		    "id"   integer primary key autoincrement,
+		    "name" varchar(100) NOT NULL
+		);
+
+		CREATE UNIQUE INDEX IF NOT EXISTS uni_band ON band (lower(name));
+
+		CREATE TABLE IF NOT EXISTS city (
+		    "id"   integer primary key autoincrement,
+		    "name" varchar(100) NOT NULL
+		);
+
+		CREATE UNIQUE INDEX IF NOT EXISTS uni_city ON city (lower(name));
+
+		CREATE TABLE IF NOT EXISTS event (
+		    "id"       integer primary key autoincrement,
+		    "title"    varchar(255) NOT NULL,
+		    "begin_dt" bigint,
+		    "end_dt"   bigint,
+		    "band_id"  integer NOT NULL REFERENCES band (id),
+		    "city_id"  integer NOT NULL REFERENCES city (id),
+		    "venue"    varchar(255),
+		    "link"     varchar(255),
+		    "img"      varchar(255)
+		);
+
+		CREATE INDEX IF NOT EXISTS ind_event_begin ON event (begin_dt);
+		CREATE INDEX IF NOT EXISTS ind_event_end ON event (end_dt);
+		CREATE INDEX IF NOT EXISTS ind_event_band ON event (band_id);
+		CREATE INDEX IF NOT EXISTS ind_event_city ON event (city_id);
+		CREATE UNIQUE INDEX IF NOT EXISTS uni_event ON event (title, begin_dt, end_dt, band_id, city_id);
+
+		CREATE VIEW IF NOT EXISTS vw_events AS
+		    SELECT e.*, c.name AS city_name, b.name AS band_name
+		    FROM event e
+		        JOIN city c ON e.city_id = c.id
+		        JOIN band b ON e.band_id = b.id;`,
+	},
+	{
+		Version: 2,
+		Script: `
+		CREATE TABLE band_alias (
+		    "alias"   varchar(100) NOT NULL,
+		    "band_id" integer NOT NULL REFERENCES band (id)
+		);
+
+		CREATE UNIQUE INDEX uni_band_alias ON band_alias (lower(alias));
+
+		CREATE TABLE city_alias (
+		    "alias"   varchar(100) NOT NULL,
+		    "city_id" integer NOT NULL REFERENCES city (id)
+		);
+
+		CREATE UNIQUE INDEX uni_city_alias ON city_alias (lower(alias));`,
+	},
+	{
+		Version: 3,
+		Script: `
+		CREATE TABLE country (
+		    "id"   integer primary key autoincrement,
+		    "name" varchar(100) NOT NULL
+		);
+
+		CREATE UNIQUE INDEX uni_country ON country (lower(name));
+
+		ALTER TABLE city ADD COLUMN "country_id" integer REFERENCES country (id);
+
+		DROP VIEW vw_events;
+
+		CREATE VIEW vw_events AS
+		    SELECT e.*, c.name AS city_name, b.name AS band_name, co.name AS country_name
+		    FROM event e
+		        JOIN city c ON e.city_id = c.id
+		        JOIN band b ON e.band_id = b.id
+		        LEFT JOIN country co ON c.country_id = co.id;`,
+	},
+	{
+		Version: 4,
+		Script: `
+		CREATE TABLE venue (
+		    "id"      integer primary key autoincrement,
+		    "name"    varchar(255) NOT NULL,
+		    "city_id" integer NOT NULL REFERENCES city (id),
+		    "address" varchar(255)
+		);
+
+		CREATE UNIQUE INDEX uni_venue ON venue (lower(name), city_id);
+
+		ALTER TABLE event ADD COLUMN "venue_id" integer REFERENCES venue (id);
+		CREATE INDEX ind_event_venue ON event (venue_id);
+
+		INSERT INTO venue (name, city_id)
+		    SELECT venue, city_id
+		    FROM event
+		    WHERE venue <> ''
+		    GROUP BY lower(venue), city_id;
+
+		UPDATE event
+		SET venue_id = (
+		    SELECT v.id
+		    FROM venue v
+		    WHERE lower(v.name) = lower(event.venue) AND v.city_id = event.city_id)
+		WHERE venue <> '';
+
+		DROP VIEW vw_events;
+
+		ALTER TABLE event DROP COLUMN venue;
+
+		CREATE VIEW vw_events AS
+		    SELECT e.*, c.name AS city_name, b.name AS band_name, co.name AS country_name,
+		           v.name AS venue_name, v.address AS venue_address
+		    FROM event e
+		        JOIN city c ON e.city_id = c.id
+		        JOIN band b ON e.band_id = b.id
+		        LEFT JOIN country co ON c.country_id = co.id
+		        LEFT JOIN venue v ON e.venue_id = v.id;`,
+	},
+	{
+		Version: 5,
+		Script: `
+		CREATE TABLE genre (
+		    "id"   integer primary key autoincrement,
+		    "name" varchar(100) NOT NULL
+		);
+
+		CREATE UNIQUE INDEX uni_genre ON genre (lower(name));
+
+		CREATE TABLE band_genre (
+		    "band_id"  integer NOT NULL REFERENCES band (id),
+		    "genre_id" integer NOT NULL REFERENCES genre (id),
+		    PRIMARY KEY (band_id, genre_id)
+		);
+
+		CREATE INDEX ind_band_genre_genre ON band_genre (genre_id);`,
+	},
+	{
+		Version: 6,
+		Script: `
+		CREATE TABLE subscription (
+		    "user_id"    varchar(50) NOT NULL,
+		    "channel_id" varchar(50) NOT NULL,
+		    "band_id"    integer NOT NULL REFERENCES band (id),
+		    PRIMARY KEY (user_id, channel_id, band_id)
+		);
+
+		CREATE INDEX ind_subscription_band ON subscription (band_id);`,
+	},
+	{
+		Version: 7,
+		Script: `
+		CREATE TABLE watch (
+		    "channel_id" varchar(50) NOT NULL,
+		    "city_id"    integer NOT NULL REFERENCES city (id),
+		    "next_run"   bigint NOT NULL,
+		    PRIMARY KEY (channel_id, city_id)
+		);
+
+		CREATE INDEX ind_watch_next_run ON watch (next_run);`,
+	},
+	{
+		// names are unique by fold_lower(), it handles not only ASCII
+		Version: 8,
+		Script: `
+		DROP INDEX uni_band;
+		CREATE UNIQUE INDEX uni_band ON band (fold_lower(name));
+
+		DROP INDEX uni_city;
+		CREATE UNIQUE INDEX uni_city ON city (fold_lower(name));
+
+		DROP INDEX uni_band_alias;
+		CREATE UNIQUE INDEX uni_band_alias ON band_alias (fold_lower(alias));
+
+		DROP INDEX uni_city_alias;
+		CREATE UNIQUE INDEX uni_city_alias ON city_alias (fold_lower(alias));
+
+		DROP INDEX uni_country;
+		CREATE UNIQUE INDEX uni_country ON country (fold_lower(name));
+
+		DROP INDEX uni_venue;
+		CREATE UNIQUE INDEX uni_venue ON venue (fold_lower(name), city_id);
+
+		DROP INDEX uni_genre;
+		CREATE UNIQUE INDEX uni_genre ON genre (fold_lower(name));`,
+	},
+}
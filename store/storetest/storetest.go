@@ -0,0 +1,422 @@
+// Package storetest contains tests shared by implementations of store.Dao.
+package storetest
+
+import (
+	"testing"
+
+	"github.com/austinov/rocker-bot/store"
+	"github.com/stretchr/testify/assert"
+)
+
+const maxTime = 1 << 62
+
+// Run runs all shared tests against daos returned by newDao,
+// each test gets a new dao without data which is closed after the test.
+func Run(t *testing.T, newDao func() store.Dao) {
+	tests := []struct {
+		name string
+		test func(*testing.T, store.Dao)
+	}{
+		{"AddBandEvents", TestAddBandEvents},
+		{"GetEvents", TestGetEvents},
+		{"FindNames", TestFindNames},
+		{"ListNames", TestListNames},
+		{"NegativeOffset", TestNegativeOffset},
+		{"Aliases", TestAliases},
+		{"Countries", TestCountries},
+		{"Venues", TestVenues},
+		{"Genres", TestGenres},
+		{"Subscriptions", TestSubscriptions},
+		{"Watches", TestWatches},
+	}
+	for _, tt := range tests {
+		test := tt.test
+		t.Run(tt.name, func(t *testing.T) {
+			dao := newDao()
+			defer dao.Close()
+			test(t, dao)
+		})
+	}
+}
+
+// TestAddBandEvents checks that band's events are replaced on each saving,
+// new events are reported and band's and city's names are case insensitive.
+func TestAddBandEvents(t *testing.T, dao store.Dao) {
+	newEvents := addBandEvents(t, dao, []store.Event{
+		{Band: "Metallica", Title: "Metallica", From: 20, To: 20, City: "Moscow"},
+		{Band: "Metallica", Title: "Metallica", From: 10, To: 10, City: "Paris"},
+		{Band: "Metallica", Title: "Metallica", From: 10, To: 10, City: "paris"},
+	})
+	assert.Equal(t, 2, len(newEvents))
+	events, err := dao.GetEvents(store.Filter{Band: "METALLICA", To: maxTime}, 0, 42)
+	assert.NoError(t, err)
+	assert.Equal(t, []store.Event{
+		{Band: "Metallica", Title: "Metallica", From: 10, To: 10, City: "Paris"},
+		{Band: "Metallica", Title: "Metallica", From: 20, To: 20, City: "Moscow"},
+	}, events)
+
+	// next load replaces band's events and reports the new ones
+	newEvents = addBandEvents(t, dao, []store.Event{
+		{Band: "metallica", Title: "Metallica", From: 30, To: 30, City: "MOSCOW"},
+		{Band: "metallica", Title: "Metallica", From: 10, To: 10, City: "PARIS"},
+	})
+	assert.Equal(t, []store.Event{
+		{Band: "metallica", Title: "Metallica", From: 30, To: 30, City: "MOSCOW"},
+	}, newEvents)
+	events, err = dao.GetEvents(store.Filter{Band: "metallica", To: maxTime}, 0, 42)
+	assert.NoError(t, err)
+	assert.Equal(t, []store.Event{
+		{Band: "Metallica", Title: "Metallica", From: 10, To: 10, City: "Paris"},
+		{Band: "Metallica", Title: "Metallica", From: 30, To: 30, City: "Moscow"},
+	}, events)
+
+	// the same load has no new events
+	newEvents = addBandEvents(t, dao, []store.Event{
+		{Band: "Metallica", Title: "Metallica", From: 30, To: 30, City: "Moscow"},
+	})
+	assert.Empty(t, newEvents)
+	events, err = dao.GetEvents(store.Filter{Band: "metallica", To: maxTime}, 0, 42)
+	assert.NoError(t, err)
+	assert.Equal(t, []store.Event{
+		{Band: "Metallica", Title: "Metallica", From: 30, To: 30, City: "Moscow"},
+	}, events)
+}
+
+// TestGetEvents checks filters, ordering, offset/limit and merging bands
+// on the same event.
+func TestGetEvents(t *testing.T, dao store.Dao) {
+	addBandEvents(t, dao, []store.Event{
+		{Band: "Slayer", Title: "Fest", From: 10, To: 12, City: "Berlin", Venue: "Arena"},
+		{Band: "Slayer", Title: "Slayer", From: 20, To: 20, City: "Helsinki"},
+	})
+	addBandEvents(t, dao, []store.Event{
+		{Band: "Anthrax", Title: "Fest", From: 10, To: 12, City: "Berlin", Venue: "Arena"},
+		{Band: "Anthrax", Title: "Anthrax", From: 30, To: 30, City: "Berlin"},
+	})
+
+	// bands on the same event are merged
+	events, err := dao.GetEvents(store.Filter{City: "berlin", To: maxTime}, 0, 42)
+	assert.NoError(t, err)
+	assert.Equal(t, []store.Event{
+		{Band: "Anthrax, Slayer", Title: "Fest", From: 10, To: 12, City: "Berlin", Venue: "Arena"},
+		{Band: "Anthrax", Title: "Anthrax", From: 30, To: 30, City: "Berlin"},
+	}, events)
+
+	events, err = dao.GetEvents(store.Filter{Band: "slayer", City: "Berlin", To: maxTime}, 0, 42)
+	assert.NoError(t, err)
+	assert.Equal(t, []store.Event{
+		{Band: "Slayer", Title: "Fest", From: 10, To: 12, City: "Berlin", Venue: "Arena"},
+	}, events)
+
+	// period
+	events, err = dao.GetEvents(store.Filter{From: 11, To: 30}, 0, 42)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(events))
+	assert.Equal(t, "Slayer", events[0].Title)
+	assert.Equal(t, "Anthrax", events[1].Title)
+
+	// offset and limit
+	events, err = dao.GetEvents(store.Filter{To: maxTime}, 1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, "Slayer", events[0].Title)
+
+	events, err = dao.GetEvents(store.Filter{Band: "Metallica", To: maxTime}, 0, 42)
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+
+	// not ASCII names
+	addBandEvents(t, dao, []store.Event{
+		{Band: "Ария", Title: "Ария", From: 40, To: 40, City: "Москва"},
+	})
+	events, err = dao.GetEvents(store.Filter{Band: "АРИЯ", City: "москва", To: maxTime}, 0, 42)
+	assert.NoError(t, err)
+	assert.Equal(t, []store.Event{
+		{Band: "Ария", Title: "Ария", From: 40, To: 40, City: "Москва"},
+	}, events)
+}
+
+// TestFindNames checks that bands and cities are found by similar names.
+func TestFindNames(t *testing.T, dao store.Dao) {
+	addBandEvents(t, dao, []store.Event{
+		{Band: "Metallica", Title: "Metallica", From: 10, To: 10, City: "Saint Petersburg"},
+	})
+	addBandEvents(t, dao, []store.Event{
+		{Band: "Motörhead", Title: "Motörhead", From: 10, To: 10, City: "Moscow"},
+	})
+
+	matches, err := dao.FindBands("metalica", 5)
+	assert.NoError(t, err)
+	if assert.Equal(t, 1, len(matches)) {
+		assert.Equal(t, "Metallica", matches[0].Name)
+		assert.InDelta(t, 0.727, matches[0].Similarity, 0.001)
+	}
+
+	matches, err = dao.FindBands("Motorhead", 5)
+	assert.NoError(t, err)
+	if assert.Equal(t, 1, len(matches)) {
+		assert.Equal(t, "Motörhead", matches[0].Name)
+	}
+
+	matches, err = dao.FindCities("St. Petersburg", 5)
+	assert.NoError(t, err)
+	if assert.Equal(t, 1, len(matches)) {
+		assert.Equal(t, "Saint Petersburg", matches[0].Name)
+	}
+
+	matches, err = dao.FindCities("Helsinki", 5)
+	assert.NoError(t, err)
+	assert.Empty(t, matches)
+}
+
+// TestListNames checks that bands and cities are listed by pages ordered by name.
+func TestListNames(t *testing.T, dao store.Dao) {
+	addBandEvents(t, dao, []store.Event{
+		{Band: "Slayer", Title: "Slayer", From: 10, To: 10, City: "Moscow"},
+	})
+	addBandEvents(t, dao, []store.Event{
+		{Band: "Metallica", Title: "Metallica", From: 10, To: 10, City: "Berlin"},
+		{Band: "Metallica", Title: "Metallica", From: 20, To: 20, City: "London"},
+	})
+	addBandEvents(t, dao, []store.Event{
+		{Band: "anthrax", Title: "Anthrax", From: 10, To: 10, City: "Berlin"},
+	})
+
+	bands, err := dao.GetBands(0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"anthrax", "Metallica", "Slayer"}, bands)
+	bands, err = dao.GetBands(1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Metallica"}, bands)
+	bands, err = dao.GetBands(3, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, bands)
+
+	cities, err := dao.GetCities(1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"London", "Moscow"}, cities)
+}
+
+// TestNegativeOffset checks that pages with negative offset are refused.
+func TestNegativeOffset(t *testing.T, dao store.Dao) {
+	addBandEvents(t, dao, []store.Event{
+		{Band: "Metallica", Title: "Metallica", From: 10, To: 10, City: "Paris"},
+	})
+
+	_, err := dao.GetEvents(store.Filter{Band: "Metallica"}, -1, 42)
+	assert.Equal(t, store.ErrNegativeOffset, err)
+	_, err = dao.GetBands(-1, 42)
+	assert.Equal(t, store.ErrNegativeOffset, err)
+	_, err = dao.GetCities(-5, 42)
+	assert.Equal(t, store.ErrNegativeOffset, err)
+}
+
+// TestAliases checks that aliases are resolved to the known names
+// while saving and getting events.
+func TestAliases(t *testing.T, dao store.Dao) {
+	assert.NoError(t, dao.AddBandAlias("AC/DC", "ACDC"))
+	assert.NoError(t, dao.AddCityAlias("Moscow", "Москва"))
+	assert.NoError(t, dao.AddCityAlias("Moscow", "Moskva"))
+
+	addBandEvents(t, dao, []store.Event{
+		{Band: "acdc", Title: "AC/DC", From: 10, To: 10, City: "Moskva"},
+		{Band: "acdc", Title: "AC/DC", From: 20, To: 20, City: "Paris"},
+	})
+	expEvents := []store.Event{
+		{Band: "AC/DC", Title: "AC/DC", From: 10, To: 10, City: "Moscow"},
+	}
+	events, err := dao.GetEvents(store.Filter{Band: "AC/DC", City: "москва", To: maxTime}, 0, 42)
+	assert.NoError(t, err)
+	assert.Equal(t, expEvents, events)
+	events, err = dao.GetEvents(store.Filter{Band: "ACDC", City: "Moscow", To: maxTime}, 0, 42)
+	assert.NoError(t, err)
+	assert.Equal(t, expEvents, events)
+
+	// alias is moved to another name
+	assert.NoError(t, dao.AddCityAlias("Paris", "Moskva"))
+	events, err = dao.GetEvents(store.Filter{Band: "ACDC", City: "Moskva", To: maxTime}, 0, 42)
+	assert.NoError(t, err)
+	assert.Equal(t, []store.Event{
+		{Band: "AC/DC", Title: "AC/DC", From: 20, To: 20, City: "Paris"},
+	}, events)
+}
+
+// TestCountries checks that cities are linked with countries
+// and events are filtered by country.
+func TestCountries(t *testing.T, dao store.Dao) {
+	addBandEvents(t, dao, []store.Event{
+		{Band: "Slayer", Title: "Slayer", From: 10, To: 10, City: "Berlin", Country: "Germany"},
+		{Band: "Slayer", Title: "Slayer", From: 20, To: 20, City: "Dresden", Country: "Germany"},
+		{Band: "Slayer", Title: "Slayer", From: 30, To: 30, City: "Paris", Country: "France"},
+		{Band: "Slayer", Title: "Slayer", From: 40, To: 40, City: "Helsinki"},
+	})
+	events, err := dao.GetEvents(store.Filter{Band: "Slayer", Country: "germany"}, 0, 42)
+	assert.NoError(t, err)
+	assert.Equal(t, []store.Event{
+		{Band: "Slayer", Title: "Slayer", From: 10, To: 10, City: "Berlin", Country: "Germany"},
+		{Band: "Slayer", Title: "Slayer", From: 20, To: 20, City: "Dresden", Country: "Germany"},
+	}, events)
+
+	// country of city is kept if the next event has no country
+	addBandEvents(t, dao, []store.Event{
+		{Band: "Anthrax", Title: "Anthrax", From: 50, To: 50, City: "Paris"},
+	})
+	events, err = dao.GetEvents(store.Filter{Country: "France"}, 0, 42)
+	assert.NoError(t, err)
+	assert.Equal(t, []store.Event{
+		{Band: "Slayer", Title: "Slayer", From: 30, To: 30, City: "Paris", Country: "France"},
+		{Band: "Anthrax", Title: "Anthrax", From: 50, To: 50, City: "Paris", Country: "France"},
+	}, events)
+
+	events, err = dao.GetEvents(store.Filter{City: "Helsinki"}, 0, 42)
+	assert.NoError(t, err)
+	assert.Equal(t, []store.Event{
+		{Band: "Slayer", Title: "Slayer", From: 40, To: 40, City: "Helsinki"},
+	}, events)
+}
+
+// TestVenues checks that venues are kept with addresses
+// and events are filtered by venue.
+func TestVenues(t *testing.T, dao store.Dao) {
+	addBandEvents(t, dao, []store.Event{
+		{Band: "Amorphis", Title: "Amorphis", From: 10, To: 10, City: "Helsinki", Venue: "Tavastia"},
+		{Band: "Amorphis", Title: "Amorphis", From: 20, To: 20, City: "Helsinki", Venue: "Nosturi"},
+	})
+	addBandEvents(t, dao, []store.Event{
+		{Band: "Children of Bodom", Title: "Children of Bodom", From: 30, To: 30, City: "Helsinki",
+			Venue: "TAVASTIA", Address: "Urho Kekkosen katu 4-6"},
+	})
+	events, err := dao.GetEvents(store.Filter{Venue: "tavastia"}, 0, 42)
+	assert.NoError(t, err)
+	assert.Equal(t, []store.Event{
+		{Band: "Amorphis", Title: "Amorphis", From: 10, To: 10, City: "Helsinki",
+			Venue: "Tavastia", Address: "Urho Kekkosen katu 4-6"},
+		{Band: "Children of Bodom", Title: "Children of Bodom", From: 30, To: 30, City: "Helsinki",
+			Venue: "Tavastia", Address: "Urho Kekkosen katu 4-6"},
+	}, events)
+
+	events, err = dao.GetEvents(store.Filter{Venue: "Tavastia", City: "Paris"}, 0, 42)
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+// TestGenres checks that band's genres are kept and events are filtered by genre.
+func TestGenres(t *testing.T, dao store.Dao) {
+	addBandEvents(t, dao, []store.Event{
+		{Band: "Candlemass", Genres: []string{"Doom Metal"}, Title: "Fest", From: 10, To: 10, City: "Berlin"},
+		{Band: "Candlemass", Genres: []string{"Doom Metal"}, Title: "Candlemass", From: 20, To: 20, City: "Oslo"},
+	})
+	addBandEvents(t, dao, []store.Event{
+		{Band: "Mayhem", Genres: []string{"Black Metal"}, Title: "Fest", From: 10, To: 10, City: "Berlin"},
+		{Band: "Mayhem", Genres: []string{"Black Metal"}, Title: "Mayhem", From: 30, To: 30, City: "Oslo"},
+	})
+
+	events, err := dao.GetEvents(store.Filter{Genre: "doom", City: "Berlin"}, 0, 42)
+	assert.NoError(t, err)
+	assert.Equal(t, []store.Event{
+		{Band: "Candlemass", Title: "Fest", From: 10, To: 10, City: "Berlin"},
+	}, events)
+
+	events, err = dao.GetEvents(store.Filter{Genre: "metal", City: "Oslo"}, 0, 42)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(events))
+
+	// wildcards of LIKE aren't special in genre's name
+	for _, genre := range []string{"d%m", "_oom", "%"} {
+		events, err = dao.GetEvents(store.Filter{Genre: genre}, 0, 42)
+		assert.NoError(t, err)
+		assert.Empty(t, events, genre)
+	}
+
+	// genres are kept if the next loading has no genres
+	addBandEvents(t, dao, []store.Event{
+		{Band: "Mayhem", Title: "Mayhem", From: 40, To: 40, City: "Oslo"},
+	})
+	events, err = dao.GetEvents(store.Filter{Genre: "Black Metal"}, 0, 42)
+	assert.NoError(t, err)
+	assert.Equal(t, []store.Event{
+		{Band: "Mayhem", Title: "Mayhem", From: 40, To: 40, City: "Oslo"},
+	}, events)
+}
+
+// TestSubscriptions checks following and unfollowing of bands.
+func TestSubscriptions(t *testing.T, dao store.Dao) {
+	assert.NoError(t, dao.AddBandAlias("AC/DC", "ACDC"))
+	assert.NoError(t, dao.Follow(store.Subscription{User: "U1", Channel: "C1", Band: "Slayer"}))
+	assert.NoError(t, dao.Follow(store.Subscription{User: "U1", Channel: "C1", Band: "acdc"}))
+	assert.NoError(t, dao.Follow(store.Subscription{User: "U1", Channel: "C1", Band: "SLAYER"}))
+	assert.NoError(t, dao.Follow(store.Subscription{User: "U2", Channel: "C1", Band: "Slayer"}))
+	assert.NoError(t, dao.Follow(store.Subscription{User: "U1", Channel: "C2", Band: "Slayer"}))
+
+	subscriptions, err := dao.GetSubscriptions("U1", "C1")
+	assert.NoError(t, err)
+	assert.Equal(t, []store.Subscription{
+		{User: "U1", Channel: "C1", Band: "AC/DC"},
+		{User: "U1", Channel: "C1", Band: "Slayer"},
+	}, subscriptions)
+
+	subscriptions, err = dao.GetFollowers("slayer")
+	assert.NoError(t, err)
+	assert.Equal(t, []store.Subscription{
+		{User: "U1", Channel: "C1", Band: "Slayer"},
+		{User: "U2", Channel: "C1", Band: "Slayer"},
+		{User: "U1", Channel: "C2", Band: "Slayer"},
+	}, subscriptions)
+
+	assert.NoError(t, dao.Unfollow(store.Subscription{User: "U1", Channel: "C1", Band: "ACDC"}))
+	assert.NoError(t, dao.Unfollow(store.Subscription{User: "U1", Channel: "C1", Band: "Metallica"}))
+	subscriptions, err = dao.GetSubscriptions("U1", "C1")
+	assert.NoError(t, err)
+	assert.Equal(t, []store.Subscription{
+		{User: "U1", Channel: "C1", Band: "Slayer"},
+	}, subscriptions)
+
+	subscriptions, err = dao.GetFollowers("AC/DC")
+	assert.NoError(t, err)
+	assert.Empty(t, subscriptions)
+}
+
+// TestWatches checks saving, selecting and removing of cities' watches.
+func TestWatches(t *testing.T, dao store.Dao) {
+	assert.NoError(t, dao.AddCityAlias("Saint Petersburg", "St Petersburg"))
+	assert.NoError(t, dao.AddWatch(store.Watch{Channel: "C1", City: "Helsinki", NextRun: 20}))
+	assert.NoError(t, dao.AddWatch(store.Watch{Channel: "C1", City: "st petersburg", NextRun: 10}))
+	assert.NoError(t, dao.AddWatch(store.Watch{Channel: "C2", City: "HELSINKI", NextRun: 30}))
+
+	watches, err := dao.GetWatches("C1")
+	assert.NoError(t, err)
+	assert.Equal(t, []store.Watch{
+		{Channel: "C1", City: "Helsinki", NextRun: 20},
+		{Channel: "C1", City: "Saint Petersburg", NextRun: 10},
+	}, watches)
+
+	watches, err = dao.GetDueWatches(20)
+	assert.NoError(t, err)
+	assert.Equal(t, []store.Watch{
+		{Channel: "C1", City: "Saint Petersburg", NextRun: 10},
+		{Channel: "C1", City: "Helsinki", NextRun: 20},
+	}, watches)
+
+	// next run is replaced
+	assert.NoError(t, dao.AddWatch(store.Watch{Channel: "C1", City: "Helsinki", NextRun: 40}))
+	watches, err = dao.GetDueWatches(30)
+	assert.NoError(t, err)
+	assert.Equal(t, []store.Watch{
+		{Channel: "C1", City: "Saint Petersburg", NextRun: 10},
+		{Channel: "C2", City: "Helsinki", NextRun: 30},
+	}, watches)
+
+	assert.NoError(t, dao.RemoveWatch("C1", "St Petersburg"))
+	watches, err = dao.GetWatches("C1")
+	assert.NoError(t, err)
+	assert.Equal(t, []store.Watch{
+		{Channel: "C1", City: "Helsinki", NextRun: 40},
+	}, watches)
+}
+
+// addBandEvents saves band's events and returns the new ones.
+func addBandEvents(t *testing.T, dao store.Dao, events []store.Event) []store.Event {
+	newEvents, err := dao.AddBandEvents(events)
+	assert.NoError(t, err)
+	return newEvents
+}
@@ -0,0 +1,147 @@
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/austinov/go-recipes/backoff"
+)
+
+// State is a state of supervised component.
+type State string
+
+const (
+	Running    State = "running"
+	Restarting State = "restarting"
+	Stopped    State = "stopped"
+	Failed     State = "failed"
+)
+
+// stableRun is a duration of run after which the component is considered
+// stable, so the backoff of its restarts is reset
+const stableRun = time.Minute
+
+// RunFunc runs the component until ctx is done. It returns error
+// if the component is crashed.
+type RunFunc func(ctx context.Context) error
+
+// Status is a status of supervised component.
+type Status struct {
+	Name     string
+	State    State
+	Restarts uint64
+	Err      error // the last error of the component
+	Since    time.Time
+}
+
+type component struct {
+	name    string
+	run     RunFunc
+	restart bool
+}
+
+// Supervisor runs components together, restarts crashed ones
+// with backoff and reports their states.
+type Supervisor struct {
+	components []component
+	mu         sync.RWMutex
+	statuses   map[string]Status
+}
+
+// New returns supervisor without components.
+func New() *Supervisor {
+	return &Supervisor{
+		statuses: make(map[string]Status),
+	}
+}
+
+// Add adds the component to be run. The component is restarted with backoff
+// if restart is true and it returns error or panics before ctx is done.
+// It must be called before Run.
+func (s *Supervisor) Add(name string, run RunFunc, restart bool) {
+	s.components = append(s.components, component{
+		name:    name,
+		run:     run,
+		restart: restart,
+	})
+}
+
+// Run runs all components and blocks until they return.
+func (s *Supervisor) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, c := range s.components {
+		wg.Add(1)
+		go func(c component) {
+			defer wg.Done()
+			s.supervise(ctx, c)
+		}(c)
+	}
+	wg.Wait()
+}
+
+// Status returns statuses of the components in order they were added.
+func (s *Supervisor) Status() []Status {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	statuses := make([]Status, 0, len(s.components))
+	for _, c := range s.components {
+		if st, ok := s.statuses[c.name]; ok {
+			statuses = append(statuses, st)
+		}
+	}
+	return statuses
+}
+
+func (s *Supervisor) supervise(ctx context.Context, c component) {
+	eb := backoff.NewExpBackoff()
+	var restarts uint64
+	for {
+		s.setStatus(Status{Name: c.name, State: Running, Restarts: restarts})
+		started := time.Now()
+		err := runSafely(ctx, c.run)
+		if err == nil || ctx.Err() != nil {
+			s.setStatus(Status{Name: c.name, State: Stopped, Restarts: restarts, Err: err})
+			return
+		}
+		if !c.restart {
+			s.setStatus(Status{Name: c.name, State: Failed, Restarts: restarts, Err: err})
+			return
+		}
+		if time.Since(started) >= stableRun {
+			eb.Reset()
+		}
+		s.setStatus(Status{Name: c.name, State: Restarting, Restarts: restarts, Err: err})
+		select {
+		case <-eb.Delay():
+		case <-ctx.Done():
+			s.setStatus(Status{Name: c.name, State: Stopped, Restarts: restarts, Err: err})
+			return
+		}
+		restarts++
+	}
+}
+
+// runSafely runs the component, panic is returned as error.
+func runSafely(ctx context.Context, run RunFunc) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return run(ctx)
+}
+
+func (s *Supervisor) setStatus(st Status) {
+	st.Since = time.Now()
+	s.mu.Lock()
+	s.statuses[st.Name] = st
+	s.mu.Unlock()
+	if st.Err != nil {
+		log.Printf("%s is %s after error: %v\n", st.Name, st.State, st.Err)
+	} else {
+		log.Printf("%s is %s.\n", st.Name, st.State)
+	}
+}
@@ -0,0 +1,64 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSupervisor(t *testing.T) {
+	s := New()
+
+	runs := 0
+	started := make(chan struct{})
+	s.Add("loader", func(ctx context.Context) error {
+		runs++
+		switch runs {
+		case 1:
+			return errors.New("crashed")
+		case 2:
+			panic("crashed")
+		}
+		close(started)
+		<-ctx.Done()
+		return nil
+	}, true)
+	s.Add("once", func(ctx context.Context) error {
+		return nil
+	}, true)
+	s.Add("critical", func(ctx context.Context) error {
+		return errors.New("crashed")
+	}, false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(stopped)
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("loader is not restarted")
+	}
+	statuses := s.Status()
+	assert.Len(t, statuses, 3)
+	assert.Equal(t, "loader", statuses[0].Name)
+	assert.Equal(t, Running, statuses[0].State)
+	assert.Equal(t, uint64(2), statuses[0].Restarts)
+	assert.Equal(t, Stopped, statuses[1].State)
+	assert.Equal(t, Failed, statuses[2].State)
+	assert.EqualError(t, statuses[2].Err, "crashed")
+
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("supervisor is not stopped")
+	}
+	assert.Equal(t, Stopped, s.Status()[0].State)
+}